@@ -0,0 +1,177 @@
+// Package albumdb is the album database shared by data-access and
+// web-service-gin: the SQL dialects they run on, the migrations that create
+// the album table, and the sizing and health checks of the connection pool.
+package albumdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect hides the differences between the databases the album programs
+// run on.
+type Dialect interface {
+	// Name is the database/sql driver name, e.g. "mysql".
+	Name() string
+	// Placeholder returns the bind parameter for the n-th (1-based) argument.
+	Placeholder(n int) string
+	// InsertID runs an INSERT and returns the ID of the new row.
+	InsertID(ctx context.Context, q Querier, query string, args ...any) (int64, error)
+	// Retryable reports whether err aborted a transaction that may succeed
+	// when run again, such as a deadlock.
+	Retryable(err error) bool
+	// Duplicate reports whether err is a unique key violation.
+	Duplicate(err error) bool
+	// Lock takes the migration lock on conn, waiting up to timeout, and
+	// returns the function that releases it. unlock gets the error the
+	// migration ended with, so a dialect with transactional DDL can roll
+	// back, and returns any error from finishing the migration.
+	Lock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (unlock func(err error) error, err error)
+}
+
+// Querier is what queries need from the database. *sql.DB, *sql.Tx and
+// *sql.Conn all have these methods.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// For returns the Dialect for a database/sql driver name.
+func For(driver string) (Dialect, error) {
+	switch driver {
+	case "mysql":
+		return MySQL, nil
+	case "sqlite3":
+		return SQLite, nil
+	}
+	return nil, fmt.Errorf("unsupported driver %q, want mysql or sqlite3", driver)
+}
+
+// Bind rewrites the ? placeholders in query to d's placeholders.
+// Queries are written with ?, which MySQL and SQLite both accept, so for
+// them Bind returns query unchanged.
+func Bind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lastInsertID runs an INSERT and reads the new ID from sql.Result, which
+// both MySQL (LAST_INSERT_ID()) and SQLite (the rowid) support.
+func lastInsertID(ctx context.Context, q Querier, query string, args ...any) (int64, error) {
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// MySQL is the Dialect of github.com/go-sql-driver/mysql.
+var MySQL Dialect = mysqlDialect{}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string           { return "mysql" }
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+func (mysqlDialect) InsertID(ctx context.Context, q Querier, query string, args ...any) (int64, error) {
+	return lastInsertID(ctx, q, query, args...)
+}
+
+// MySQL error numbers, see https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlDeadlock        = 1213 // ER_LOCK_DEADLOCK
+	mysqlLockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT
+	mysqlDupEntry        = 1062 // ER_DUP_ENTRY
+)
+
+func (mysqlDialect) Retryable(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && (myErr.Number == mysqlDeadlock || myErr.Number == mysqlLockWaitTimeout)
+}
+
+func (mysqlDialect) Duplicate(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == mysqlDupEntry
+}
+
+// Lock uses GET_LOCK, an advisory lock owned by the connection that took it.
+func (mysqlDialect) Lock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (func(error) error, error) {
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, int(timeout.Seconds())).Scan(&got); err != nil {
+		return nil, err
+	}
+	if got.Int64 != 1 {
+		return nil, fmt.Errorf("another process still holds %s after %v", name, timeout)
+	}
+	// MySQL commits DDL implicitly, so a failed migration can't be rolled back.
+	return func(error) error {
+		_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", name)
+		return err
+	}, nil
+}
+
+// SQLite is the Dialect of github.com/mattn/go-sqlite3.
+var SQLite Dialect = sqliteDialect{}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string           { return "sqlite3" }
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+func (sqliteDialect) InsertID(ctx context.Context, q Querier, query string, args ...any) (int64, error) {
+	return lastInsertID(ctx, q, query, args...)
+}
+
+// Retryable treats SQLITE_BUSY and SQLITE_LOCKED as retryable: SQLite has
+// no deadlock detector, a writer that can't get the lock gets one of these.
+func (sqliteDialect) Retryable(err error) bool {
+	var liteErr sqlite3.Error
+	return errors.As(err, &liteErr) && (liteErr.Code == sqlite3.ErrBusy || liteErr.Code == sqlite3.ErrLocked)
+}
+
+func (sqliteDialect) Duplicate(err error) bool {
+	var liteErr sqlite3.Error
+	return errors.As(err, &liteErr) &&
+		(liteErr.ExtendedCode == sqlite3.ErrConstraintUnique || liteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+// Lock starts an IMMEDIATE transaction, which takes SQLite's write lock on
+// the whole database file. SQLite's DDL is transactional, so unlock commits
+// everything that ran on conn since, or rolls it all back after an error.
+func (sqliteDialect) Lock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (func(error) error, error) {
+	if _, err := conn.ExecContext(ctx, "PRAGMA busy_timeout = "+strconv.FormatInt(timeout.Milliseconds(), 10)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return func(err error) error {
+		if err != nil {
+			conn.ExecContext(context.Background(), "ROLLBACK")
+			return nil
+		}
+		_, err = conn.ExecContext(context.Background(), "COMMIT")
+		return err
+	}, nil
+}
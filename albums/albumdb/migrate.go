@@ -0,0 +1,387 @@
+package albumdb
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change, read from a pair of files
+// migrations/<dialect>/<version>_<name>.up.sql and .down.sql. Each dialect
+// has its own copy since their DDL differs (AUTO_INCREMENT vs AUTOINCREMENT).
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a Migration and when it was applied, if it was.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time // nil if pending
+}
+
+// loadMigrations reads every *.up.sql and *.down.sql file in the root of fsys,
+// sorted by version. Each version needs both files.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, name := range names {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		versionText, label, ok2 := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionText, 10, 64)
+		if !ok || !ok2 || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: want <version>_<name>.up.sql or .down.sql", name)
+		}
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and reverts migrations, recording the applied versions in
+// the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	d          Dialect
+	migrations []Migration
+
+	// LockTimeout is how long to wait for another process's migration to finish.
+	LockTimeout time.Duration
+}
+
+// NewMigrator returns a Migrator for the migrations embedded in the binary
+// for dialect d.
+func NewMigrator(db *sql.DB, d Dialect) (*Migrator, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations/"+d.Name())
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("migrate: no migrations for %s", d.Name())
+	}
+	return &Migrator{db: db, d: d, migrations: migrations, LockTimeout: 30 * time.Second}, nil
+}
+
+// migrationLock names the advisory lock that keeps two processes from
+// migrating the same database at once.
+const migrationLock = "recordings.schema_migrations"
+
+// session runs fn on a single connection that holds the migration lock.
+// Locks such as MySQL's GET_LOCK belong to the connection that took them,
+// which is why every statement of a migration has to go through conn.
+func (m *Migrator) session(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.d.Lock(ctx, conn, migrationLock, m.LockTimeout)
+	if err != nil {
+		return fmt.Errorf("migrate: lock: %w", err)
+	}
+	defer func() {
+		if unlockErr := unlock(err); err == nil && unlockErr != nil {
+			err = fmt.Errorf("migrate: unlock: %w", unlockErr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version    BIGINT NOT NULL,
+  name       VARCHAR(255) NOT NULL,
+  applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (version)
+)`); err != nil {
+		return fmt.Errorf("migrate: create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+// applied returns the applied versions and when they were applied.
+func applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := map[int64]time.Time{}
+	for rows.Next() {
+		var v int64
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		versions[v] = at
+	}
+	return versions, rows.Err()
+}
+
+// Up applies every pending migration in version order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.session(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := versions[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and
+// returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.session(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := versions[mig.Version]; !ok {
+				continue
+			}
+			if err := m.revert(ctx, conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Redo reverts the latest applied migration and applies it again, which is
+// handy while writing a migration.
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	var redone Migration
+	err := m.session(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := versions[mig.Version]; !ok {
+				continue
+			}
+			if err := m.revert(ctx, conn, mig); err != nil {
+				return err
+			}
+			redone = mig
+			return m.apply(ctx, conn, mig)
+		}
+		return errors.New("migrate: nothing to redo")
+	})
+	return redone, err
+}
+
+// Baseline records every migration up to version as applied without running
+// it, for a database whose album table was created before the migrations,
+// e.g. with the create-tables.sql data-access used to ship. It returns the
+// migrations it recorded; later ones are left for Up.
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	if !slices.ContainsFunc(m.migrations, func(mig Migration) bool { return mig.Version == version }) {
+		return nil, fmt.Errorf("migrate: no migration %d", version)
+	}
+	var done []Migration
+	err := m.session(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			if _, ok := versions[mig.Version]; ok {
+				continue
+			}
+			if err := m.record(ctx, conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.session(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			s := MigrationStatus{Migration: mig}
+			if at, ok := versions[mig.Version]; ok {
+				s.AppliedAt = &at
+			}
+			statuses = append(statuses, s)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// ErrDuplicateAlbums is the error of 0003_unique_album on an album table
+// that holds a title and artist more than once.
+var ErrDuplicateAlbums = errors.New("album has titles and artists more than once")
+
+// checks run before the up script of the migration they are named after. A
+// check stops a migration the database can't make without losing data, with
+// an error saying what has to be fixed by hand first.
+var checks = map[string]func(ctx context.Context, conn *sql.Conn) error{
+	"unique_album": checkUniqueAlbums,
+}
+
+// checkUniqueAlbums reports the ids of every title and artist the unique
+// index of 0003_unique_album can't be built over. Which copy to keep is up
+// to whoever owns the data, so none is deleted.
+func checkUniqueAlbums(ctx context.Context, conn *sql.Conn) error {
+	rows, err := conn.QueryContext(ctx, `SELECT title, artist, GROUP_CONCAT(id) FROM album
+  GROUP BY title, artist HAVING COUNT(*) > 1 ORDER BY MIN(id)`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var dups []string
+	for rows.Next() {
+		var title, artist, ids string
+		if err := rows.Scan(&title, &artist, &ids); err != nil {
+			return err
+		}
+		dups = append(dups, fmt.Sprintf("%q by %q (ids %s)", title, artist, ids))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(dups) > 0 {
+		return fmt.Errorf("%w, delete or rename all but one of each: %s", ErrDuplicateAlbums, strings.Join(dups, "; "))
+	}
+	return nil
+}
+
+// apply runs the up script of mig and records it as applied.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	if check := checks[mig.Name]; check != nil {
+		if err := check(ctx, conn); err != nil {
+			return fmt.Errorf("migrate: %d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+	if err := run(ctx, conn, mig, mig.Up); err != nil {
+		return err
+	}
+	return m.record(ctx, conn, mig)
+}
+
+// record marks mig as applied.
+func (m *Migrator) record(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	if _, err := conn.ExecContext(ctx, Bind(m.d, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)"), mig.Version, mig.Name); err != nil {
+		return fmt.Errorf("migrate: record %d: %w", mig.Version, err)
+	}
+	return nil
+}
+
+// revert runs the down script of mig and forgets that it was applied.
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	if err := run(ctx, conn, mig, mig.Down); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, Bind(m.d, "DELETE FROM schema_migrations WHERE version = ?"), mig.Version); err != nil {
+		return fmt.Errorf("migrate: unrecord %d: %w", mig.Version, err)
+	}
+	return nil
+}
+
+// run executes the statements of one migration file.
+func run(ctx context.Context, conn *sql.Conn, mig Migration, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrate: %d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a SQL script into statements at semicolons that end
+// a line, because the driver runs one statement per Exec. Lines starting
+// with -- are comments.
+func splitStatements(script string) []string {
+	var stmts []string
+	var cur strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		cur.WriteString(line)
+		cur.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(cur.String()), ";"))
+			cur.Reset()
+		}
+	}
+	if s := strings.TrimSpace(cur.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
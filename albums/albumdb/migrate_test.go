@@ -0,0 +1,63 @@
+package albumdb
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// TestEmbeddedMigrations checks that the shipped migrations load, are in
+// order, and that every dialect has the same versions.
+func TestEmbeddedMigrations(t *testing.T) {
+	var names [2]string
+	for i, d := range []Dialect{MySQL, SQLite} {
+		sub, _ := fs.Sub(migrationFiles, "migrations/"+d.Name())
+		migrations, err := loadMigrations(sub)
+		if err != nil {
+			t.Fatalf("%s: %v", d.Name(), err)
+		}
+		if len(migrations) < 2 || migrations[0].Name != "create_album" || migrations[1].Name != "seed_albums" {
+			t.Errorf("%s: loadMigrations = %+v, want create_album then seed_albums", d.Name(), migrations)
+		}
+		for _, m := range migrations {
+			names[i] += fmt.Sprintf("%d_%s ", m.Version, m.Name)
+		}
+	}
+	if names[0] != names[1] {
+		t.Errorf("mysql migrations %s differ from sqlite migrations %s", names[0], names[1])
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing down": {"0001_a.up.sql": {Data: []byte("x")}},
+		"bad name":     {"a.up.sql": {Data: []byte("x")}, "a.down.sql": {Data: []byte("x")}},
+		"name clash":   {"0001_a.up.sql": {Data: []byte("x")}, "0001_b.down.sql": {Data: []byte("x")}},
+	}
+	for name, fsys := range tests {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: loadMigrations succeeded, want error", name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+CREATE TABLE a (
+  id INT
+);
+
+INSERT INTO a VALUES (1);
+INSERT INTO a VALUES (2)`
+	got := splitStatements(script)
+	want := []string{"CREATE TABLE a (\n  id INT\n)", "INSERT INTO a VALUES (1)", "INSERT INTO a VALUES (2)"}
+	if len(got) != len(want) {
+		t.Fatalf("splitStatements = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("statement %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
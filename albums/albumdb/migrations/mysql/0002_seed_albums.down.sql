@@ -0,0 +1,5 @@
+DELETE FROM album WHERE (title, artist) IN (
+  ('Blue Train', 'John Coltrane'),
+  ('Jeru', 'Gerry Mulligan'),
+  ('Sarah Vaughan and Clifford Brown', 'Sarah Vaughan')
+);
@@ -0,0 +1 @@
+DROP INDEX album_title_artist ON album;
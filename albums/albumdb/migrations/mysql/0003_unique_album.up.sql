@@ -0,0 +1,4 @@
+-- One album per title and artist, so AlbumRepository can report ErrDuplicateAlbum.
+-- An album table that already holds one twice fails this migration, listing
+-- the ids (see checkUniqueAlbums); nothing is deleted for you.
+CREATE UNIQUE INDEX album_title_artist ON album (title, artist);
@@ -0,0 +1,7 @@
+-- The albums web-service-gin starts with.
+INSERT INTO album
+  (title, artist, price)
+VALUES
+  ('Blue Train', 'John Coltrane', 56.99),
+  ('Jeru', 'Gerry Mulligan', 17.99),
+  ('Sarah Vaughan and Clifford Brown', 'Sarah Vaughan', 39.99);
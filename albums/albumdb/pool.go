@@ -0,0 +1,176 @@
+package albumdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Pool sizes the connection pool of a database.
+type Pool struct {
+	// MaxOpen connections exist at once, 0 for no limit; further queries
+	// wait, which shows up as WaitCount and WaitDuration in db.Stats().
+	MaxOpen int
+	// MaxIdle connections are kept open between queries.
+	MaxIdle int
+	// MaxLifetime is how long a connection is reused, 0 for no limit. It
+	// should be below MySQL's wait_timeout (or a load balancer's), so the
+	// pool never hands out a connection the server has already closed.
+	MaxLifetime time.Duration
+}
+
+// DefaultPool is the pool size of both album programs unless configured.
+var DefaultPool = Pool{MaxOpen: 10, MaxIdle: 5, MaxLifetime: 30 * time.Minute}
+
+// PoolFromEnv returns DefaultPool changed by the environment, read through
+// getenv:
+//
+//	DB_MAX_OPEN_CONNS     max open connections, 0 for no limit (default 10)
+//	DB_MAX_IDLE_CONNS     idle connections kept between queries (default 5)
+//	DB_CONN_MAX_LIFETIME  how long a connection is reused, e.g. 30m (default 30m)
+func PoolFromEnv(getenv func(string) string) (Pool, error) {
+	p := DefaultPool
+	for _, n := range []struct {
+		name string
+		dst  *int
+	}{{"DB_MAX_OPEN_CONNS", &p.MaxOpen}, {"DB_MAX_IDLE_CONNS", &p.MaxIdle}} {
+		if v := getenv(n.name); v != "" {
+			var err error
+			if *n.dst, err = strconv.Atoi(v); err != nil {
+				return Pool{}, fmt.Errorf("%s: %v", n.name, err)
+			}
+		}
+	}
+	if v := getenv("DB_CONN_MAX_LIFETIME"); v != "" {
+		var err error
+		if p.MaxLifetime, err = time.ParseDuration(v); err != nil {
+			return Pool{}, fmt.Errorf("DB_CONN_MAX_LIFETIME: %v", err)
+		}
+	}
+	return p, nil
+}
+
+// Open opens the database of a database/sql driver, mysql or sqlite3, and
+// sizes its connection pool. Like sql.Open it doesn't connect; the first
+// query or ping does.
+func Open(driver, dsn string, p Pool) (*sql.DB, Dialect, error) {
+	d, err := For(driver)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	db.SetMaxOpenConns(p.MaxOpen)
+	db.SetMaxIdleConns(p.MaxIdle)
+	db.SetConnMaxLifetime(p.MaxLifetime)
+	return db, d, nil
+}
+
+// Delays between the pings to a database that is down.
+const (
+	MinBackoff = 500 * time.Millisecond
+	MaxBackoff = 30 * time.Second
+)
+
+// Backoff returns the delay before the next ping after n failed ones,
+// doubling from MinBackoff up to MaxBackoff.
+func Backoff(n int) time.Duration {
+	d := MinBackoff
+	for i := 1; i < n && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, MaxBackoff)
+}
+
+// PingWithBackoff pings db until it answers or ctx is done, waiting Backoff
+// between attempts. It lets a program start while the database is still
+// coming up, e.g. next to it in docker compose.
+func PingWithBackoff(ctx context.Context, db *sql.DB) error {
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		wait := Backoff(attempt)
+		log.Printf("ping %d failed, retrying in %v: %v", attempt, wait, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not reachable: %w", err)
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Prober pings a database in the background and remembers whether it
+// answered. database/sql drops a connection that fails and dials a new one
+// on the next use, so each ping after an outage is also a reconnect attempt.
+type Prober struct {
+	db       *sql.DB
+	interval time.Duration // between pings while the database is up
+	timeout  time.Duration // for a single ping
+
+	mu     sync.Mutex
+	status ProbeStatus
+}
+
+// ProbeStatus is the result of a Prober's latest ping.
+type ProbeStatus struct {
+	Healthy  bool
+	LastPing time.Time
+	Failures int   // consecutive failed pings
+	Err      error // of the latest ping, nil if it answered
+}
+
+// NewProber returns a Prober that pings db every interval while it is up.
+func NewProber(db *sql.DB, interval time.Duration) *Prober {
+	return &Prober{db: db, interval: interval, timeout: 2 * time.Second}
+}
+
+// Run pings until ctx is done. While the database is down it retries after
+// Backoff of the failures so far.
+func (p *Prober) Run(ctx context.Context) {
+	for {
+		wait := p.interval
+		if err := p.Probe(ctx); err != nil {
+			wait = Backoff(p.Status().Failures)
+			log.Printf("db: ping failed, retrying in %v: %v", wait, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Probe pings the database once and records the result.
+func (p *Prober) Probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	err := p.db.PingContext(ctx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil && p.status.Failures > 0 {
+		log.Printf("db: reconnected after %d failed pings", p.status.Failures)
+	}
+	failures := 0
+	if err != nil {
+		failures = p.status.Failures + 1
+	}
+	p.status = ProbeStatus{Healthy: err == nil, LastPing: time.Now(), Failures: failures, Err: err}
+	return err
+}
+
+// Status returns the result of the latest ping.
+func (p *Prober) Status() ProbeStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
@@ -0,0 +1,31 @@
+package albumdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	for n, want := range map[int]time.Duration{
+		1:  MinBackoff,
+		2:  2 * MinBackoff,
+		4:  8 * MinBackoff,
+		50: MaxBackoff,
+	} {
+		if got := Backoff(n); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestPoolFromEnv(t *testing.T) {
+	env := map[string]string{"DB_MAX_OPEN_CONNS": "20", "DB_CONN_MAX_LIFETIME": "5m"}
+	p, err := PoolFromEnv(func(k string) string { return env[k] })
+	if want := (Pool{MaxOpen: 20, MaxIdle: 5, MaxLifetime: 5 * time.Minute}); err != nil || p != want {
+		t.Errorf("PoolFromEnv = %+v, %v; want %+v", p, err, want)
+	}
+	env["DB_MAX_IDLE_CONNS"] = "five"
+	if _, err := PoolFromEnv(func(k string) string { return env[k] }); err == nil {
+		t.Error("PoolFromEnv with DB_MAX_IDLE_CONNS=five succeeded")
+	}
+}
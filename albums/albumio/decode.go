@@ -0,0 +1,226 @@
+// Package albumio reads the album files of data-access's import command and
+// web-service-gin's POST /albums:bulk, streaming them one album at a time.
+package albumio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"example.com/money"
+)
+
+// Formats of album files:
+//
+//	csv    a header row naming the columns (title, artist, price, optionally id)
+//	jsonl  one JSON album per line (JSON Lines)
+//	json   a JSON array of albums
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatJSON  = "json"
+)
+
+// Album is an album read from a file. ID is "" when the file has none; what
+// an ID means is up to the program reading the file.
+type Album struct {
+	ID     string
+	Title  string
+	Artist string
+	Price  money.Amount // in USD
+}
+
+// RowError is a row that couldn't be read. Row counts the albums in the
+// file from 1, not counting a CSV header.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string { return fmt.Sprintf("row %d: %v", e.Row, e.Err) }
+func (e *RowError) Unwrap() error { return e.Err }
+
+// ParsePrice reads a price in US dollars such as "56.99". A missing price
+// is zero. One with more than two decimals is an error wrapping
+// money.ErrPrecision rather than being rounded.
+func ParsePrice(s string) (money.Amount, error) {
+	if s = strings.TrimSpace(s); s == "" {
+		return money.Zero(money.USD), nil
+	}
+	p, err := money.Parse(s, money.USD)
+	if err != nil {
+		return money.Amount{}, fmt.Errorf("price: %w", err)
+	}
+	return p, nil
+}
+
+// albumJSON is the JSON form of an Album. The price is a JSON number whose
+// text is read exactly, not through a float.
+type albumJSON struct {
+	ID     jsonID      `json:"id"`
+	Title  string      `json:"title"`
+	Artist string      `json:"artist"`
+	Price  json.Number `json:"price"`
+}
+
+// jsonID is an id that is either a JSON string, as web-service-gin writes
+// it, or a number, as data-access exports it.
+type jsonID string
+
+func (id *jsonID) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err == nil {
+		*id = jsonID(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.New("id must be a string or a number")
+	}
+	*id = jsonID(s)
+	return nil
+}
+
+func (a albumJSON) album() (Album, error) {
+	price, err := ParsePrice(string(a.Price))
+	if err != nil {
+		return Album{}, err
+	}
+	return Album{ID: string(a.ID), Title: a.Title, Artist: a.Artist, Price: price}, nil
+}
+
+// Decoder reads the albums of a file one at a time, so a file of any size
+// is read in constant memory. Next returns io.EOF at the end, a *RowError
+// for a row that can be skipped, and any other error when the rest of the
+// file can't be read.
+type Decoder interface {
+	Next() (Album, error)
+}
+
+// NewDecoder returns the Decoder of format for r.
+func NewDecoder(format string, r io.Reader) (Decoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVDecoder(r)
+	case FormatJSONL:
+		return &jsonlDecoder{r: bufio.NewReader(r)}, nil
+	case FormatJSON:
+		return &jsonDecoder{d: json.NewDecoder(r)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, want csv, jsonl or json", format)
+}
+
+type csvDecoder struct {
+	r    *csv.Reader
+	cols map[string]int // column name to index
+	row  int
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+	d := &csvDecoder{r: cr, cols: map[string]int{}}
+	for i, name := range header {
+		d.cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"title", "artist", "price"} {
+		if _, ok := d.cols[name]; !ok {
+			return nil, fmt.Errorf("csv header has no %s column", name)
+		}
+	}
+	return d, nil
+}
+
+func (d *csvDecoder) Next() (Album, error) {
+	record, err := d.r.Read()
+	if err == io.EOF {
+		return Album{}, io.EOF
+	}
+	d.row++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+		return Album{}, &RowError{Row: d.row, Err: err}
+	} else if err != nil {
+		return Album{}, err
+	}
+	a := Album{Title: record[d.cols["title"]], Artist: record[d.cols["artist"]]}
+	if i, ok := d.cols["id"]; ok {
+		a.ID = record[i]
+	}
+	if a.Price, err = ParsePrice(record[d.cols["price"]]); err != nil {
+		return Album{}, &RowError{Row: d.row, Err: err}
+	}
+	return a, nil
+}
+
+type jsonlDecoder struct {
+	r   *bufio.Reader
+	row int
+}
+
+func (d *jsonlDecoder) Next() (Album, error) {
+	for {
+		line, err := d.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return Album{}, err // io.EOF, or a read error
+			}
+			continue // blank lines don't count as rows
+		}
+		d.row++
+		var a albumJSON
+		if err := json.Unmarshal(line, &a); err != nil {
+			return Album{}, &RowError{Row: d.row, Err: err}
+		}
+		alb, err := a.album()
+		if err != nil {
+			return Album{}, &RowError{Row: d.row, Err: err}
+		}
+		return alb, nil
+	}
+}
+
+type jsonDecoder struct {
+	d       *json.Decoder
+	started bool
+	row     int
+}
+
+func (d *jsonDecoder) Next() (Album, error) {
+	if !d.started {
+		// Read the opening [ and then decode the elements one by one,
+		// rather than unmarshalling the whole array at once.
+		if tok, err := d.d.Token(); err != nil || tok != json.Delim('[') {
+			return Album{}, errors.New("json: want an array of albums")
+		}
+		d.started = true
+	}
+	if !d.d.More() {
+		return Album{}, io.EOF
+	}
+	d.row++
+	// A raw value first, so an album with wrongly typed fields can be
+	// skipped; a syntax error leaves the decoder lost and ends the file.
+	var raw json.RawMessage
+	if err := d.d.Decode(&raw); err != nil {
+		return Album{}, fmt.Errorf("row %d: %w", d.row, err)
+	}
+	var a albumJSON
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return Album{}, &RowError{Row: d.row, Err: err}
+	}
+	alb, err := a.album()
+	if err != nil {
+		return Album{}, &RowError{Row: d.row, Err: err}
+	}
+	return alb, nil
+}
@@ -0,0 +1,51 @@
+package albumio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"example.com/money"
+)
+
+func TestDecoder(t *testing.T) {
+	tests := []struct {
+		format, file string
+		want         []string // per row: the id and price read, or the error
+	}{
+		{FormatCSV, "id,title,artist,price\n7,A,B,1.5\n,C,D,9.001\nshort\n,E,F,\n", []string{"7 1.50", "row 2: precision", "row 3: bad row", " 0.00"}},
+		{FormatJSONL, "{\"id\":7,\"title\":\"A\",\"price\":1}\n\n{\"id\":\"x\",\"price\":\"1\"}\n{\"id\":[]}\n", []string{"7 1.00", "x 1.00", "row 3: bad row"}},
+		{FormatJSON, `[{"title":"A","price":2},{"title":1},{"title":`, []string{" 2.00", "row 2: bad row", "stop"}},
+	}
+	for _, tt := range tests {
+		dec, err := NewDecoder(tt.format, strings.NewReader(tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for {
+			a, err := dec.Next()
+			var rowErr *RowError
+			switch {
+			case err == io.EOF:
+			case errors.Is(err, money.ErrPrecision) && errors.As(err, &rowErr):
+				got = append(got, fmt.Sprintf("row %d: precision", rowErr.Row))
+				continue
+			case errors.As(err, &rowErr):
+				got = append(got, fmt.Sprintf("row %d: bad row", rowErr.Row))
+				continue
+			case err != nil:
+				got = append(got, "stop")
+			default:
+				got = append(got, a.ID+" "+a.Price.Decimal())
+				continue
+			}
+			break
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s rows = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+module example.com/albums
+
+go 1.25.0
+
+require (
+	example.com/money v0.0.0-00010101000000-000000000000
+	github.com/go-sql-driver/mysql v1.9.3
+	github.com/mattn/go-sqlite3 v1.14.32
+)
+
+require filippo.io/edwards25519 v1.1.0 // indirect
+
+// shared with data-access, web-service-gin and moduleDemo
+replace example.com/money => ../money
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+
+	"example.com/albums/albumio"
+	"example.com/money"
+)
+
+// albumJSON is the JSON form of an Album in export files. The price is a
+// JSON number, written exactly rather than through a float.
+type albumJSON struct {
+	ID     int64       `json:"id,omitempty"`
+	Title  string      `json:"title"`
+	Artist string      `json:"artist"`
+	Price  json.Number `json:"price"`
+}
+
+func toJSON(a Album) albumJSON {
+	return albumJSON{ID: a.ID, Title: a.Title, Artist: a.Artist, Price: json.Number(a.Price.Decimal())}
+}
+
+// ImportResult reports how an import went.
+type ImportResult struct {
+	Imported int
+	Errors   []*albumio.RowError // rows that were skipped, by row number
+}
+
+// importAlbums adds the albums read by dec to the database, batchSize rows per
+// transaction. A row that is malformed, invalid or a duplicate is recorded in
+// the result and skipped; the import goes on. A file that can't be read any
+// further stops it after the rows before the damage are stored. A database
+// error stops it too, rolling back the batch in progress; earlier batches
+// stay committed.
+//
+// An id in the file is ignored; the database assigns a new one, so an
+// export can be imported into another database.
+func importAlbums(ctx context.Context, repo *AlbumRepository, dec albumio.Decoder, batchSize int) (result ImportResult, err error) {
+	// Rows that fail to decode are reported straight away, rows the
+	// database rejects when their batch is flushed.
+	defer func() {
+		slices.SortFunc(result.Errors, func(a, b *albumio.RowError) int { return a.Row - b.Row })
+	}()
+	type row struct {
+		n   int
+		alb Album
+	}
+	batch := make([]row, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		var imported int
+		var skipped []*albumio.RowError
+		err := repo.WithTx(ctx, func(tx *AlbumRepository) error {
+			// WithTx may run this again after a deadlock, so start over.
+			imported, skipped = 0, nil
+			for _, r := range batch {
+				_, err := tx.Create(ctx, r.alb)
+				var invalid *ValidationError
+				switch {
+				case errors.As(err, &invalid), errors.Is(err, ErrDuplicateAlbum):
+					skipped = append(skipped, &albumio.RowError{Row: r.n, Err: err})
+				case err != nil:
+					return &albumio.RowError{Row: r.n, Err: err}
+				default:
+					imported++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		result.Imported += imported
+		result.Errors = append(result.Errors, skipped...)
+		batch = batch[:0]
+		return nil
+	}
+
+	for n := 1; ; n++ {
+		a, err := dec.Next()
+		var rowErr *albumio.RowError
+		switch {
+		case err == io.EOF:
+			err = flush()
+			return result, err
+		case errors.As(err, &rowErr):
+			if errors.Is(rowErr, money.ErrPrecision) {
+				// Not rounded, but invalid like a negative price.
+				invalid := &ValidationError{Fields: map[string]string{"Price": "must have at most two decimals"}}
+				rowErr = &albumio.RowError{Row: rowErr.Row, Err: invalid}
+			}
+			result.Errors = append(result.Errors, rowErr)
+			continue
+		case err != nil:
+			if flushErr := flush(); flushErr != nil {
+				return result, flushErr
+			}
+			return result, err
+		}
+		batch = append(batch, row{n: n, alb: Album{Title: a.Title, Artist: a.Artist, Price: a.Price}})
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+// exportAlbums writes every album to w in format, streaming them from the
+// database one row at a time.
+func exportAlbums(ctx context.Context, repo *AlbumRepository, w io.Writer, format string) error {
+	bw := bufio.NewWriter(w)
+	var write func(Album) error
+	finish := func() error { return nil }
+
+	switch format {
+	case albumio.FormatCSV:
+		cw := csv.NewWriter(bw)
+		if err := cw.Write([]string{"id", "title", "artist", "price"}); err != nil {
+			return err
+		}
+		write = func(a Album) error {
+			return cw.Write([]string{
+				strconv.FormatInt(a.ID, 10), a.Title, a.Artist,
+				a.Price.Decimal(),
+			})
+		}
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case albumio.FormatJSONL:
+		enc := json.NewEncoder(bw)
+		write = func(a Album) error { return enc.Encode(toJSON(a)) }
+	case albumio.FormatJSON:
+		// One album per line inside the brackets, written as it is read.
+		sep := "[\n"
+		write = func(a Album) error {
+			b, err := json.Marshal(toJSON(a))
+			if err != nil {
+				return err
+			}
+			bw.WriteString(sep)
+			sep = ",\n"
+			_, err = bw.Write(b)
+			return err
+		}
+		finish = func() error {
+			if sep == "[\n" {
+				_, err := bw.WriteString("[]\n") // no albums
+				return err
+			}
+			_, err := bw.WriteString("\n]\n")
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %q, want csv, jsonl or json", format)
+	}
+
+	if err := repo.Each(ctx, AlbumFilter{}, write); err != nil {
+		return err
+	}
+	if err := finish(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// runImport implements `go run . import [-format csv|jsonl|json] [-batch N] [file]`.
+// Without a file it reads standard input. Skipped rows are listed on standard error.
+func runImport(ctx context.Context, repo *AlbumRepository, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", albumio.FormatCSV, "file format: csv, jsonl or json")
+	batchSize := fs.Int("batch", 500, "albums inserted per transaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *batchSize < 1 {
+		return fmt.Errorf("import: -batch must be at least 1")
+	}
+
+	var in io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	dec, err := albumio.NewDecoder(*format, in)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	result, err := importAlbums(ctx, repo, dec, *batchSize)
+	for _, rowErr := range result.Errors {
+		fmt.Fprintln(os.Stderr, rowErr)
+	}
+	fmt.Printf("imported %d albums, skipped %d rows\n", result.Imported, len(result.Errors))
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	return nil
+}
+
+// runExport implements `go run . export [-format csv|jsonl|json]`, writing to standard output.
+func runExport(ctx context.Context, repo *AlbumRepository, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", albumio.FormatCSV, "file format: csv, jsonl or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := exportAlbums(ctx, repo, os.Stdout, *format); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	return nil
+}
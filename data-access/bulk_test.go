@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"example.com/albums/albumio"
+)
+
+func TestImportAlbums(t *testing.T) {
+	ctx := context.Background()
+	albums, _ := openTestDB(t)
+
+	const file = `title,artist,price
+Giant Steps,John Coltrane,63.99
+Blue Train,John Coltrane,56.99
+,Nobody,1
+Bad,X,abc
+short
+Kind of Blue,Miles Davis,12.5
+Round Midnight,Miles Davis,9.001
+`
+	dec, err := albumio.NewDecoder(albumio.FormatCSV, strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := importAlbums(ctx, albums, dec, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 2 {
+		t.Errorf("Imported = %d, want 2", result.Imported)
+	}
+	var rows []int
+	for _, e := range result.Errors {
+		rows = append(rows, e.Row)
+	}
+	if len(rows) != 5 || rows[0] != 2 || rows[1] != 3 || rows[2] != 4 || rows[3] != 5 || rows[4] != 7 {
+		t.Errorf("rows with errors = %v, want [2 3 4 5 7]", rows)
+	}
+	if !errors.Is(result.Errors[0], ErrDuplicateAlbum) {
+		t.Errorf("row 2 error = %v, want ErrDuplicateAlbum", result.Errors[0])
+	}
+	if invalid := new(ValidationError); !errors.As(result.Errors[4], &invalid) || invalid.Fields["Price"] == "" {
+		t.Errorf("row 7 error = %v, want a *ValidationError on Price", result.Errors[4])
+	}
+	if n, _ := albums.Count(ctx, AlbumFilter{}); n != 5 {
+		t.Errorf("Count = %d, want 5", n)
+	}
+
+	// A JSON array stops at a syntax error but keeps what was imported.
+	dec, _ = albumio.NewDecoder(albumio.FormatJSON, strings.NewReader(`[{"title":"A","artist":"B","price":1},{"title":2},{"title":`))
+	result, err = importAlbums(ctx, albums, dec, 10)
+	if err == nil || result.Imported != 1 || len(result.Errors) != 1 {
+		t.Errorf("import of a broken array = %+v, %v; want 1 imported, 1 skipped and an error", result, err)
+	}
+}
+
+func TestExportAlbums(t *testing.T) {
+	ctx := context.Background()
+	albums, _ := openTestDB(t)
+
+	for format, want := range map[string]string{
+		albumio.FormatCSV:   "id,title,artist,price\n1,Blue Train,John Coltrane,56.99\n",
+		albumio.FormatJSONL: `{"id":1,"title":"Blue Train","artist":"John Coltrane","price":56.99}` + "\n",
+		albumio.FormatJSON:  "[\n" + `{"id":1,"title":"Blue Train","artist":"John Coltrane","price":56.99},` + "\n",
+	} {
+		var buf bytes.Buffer
+		if err := exportAlbums(ctx, albums, &buf, format); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), want) {
+			t.Errorf("%s export starts with %q, want %q", format, buf.String()[:min(len(want), buf.Len())], want)
+		}
+
+		// Each export can be read back by the import of its format.
+		dec, err := albumio.NewDecoder(format, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for {
+			if _, err := dec.Next(); err != nil {
+				break
+			}
+			n++
+		}
+		if n != 3 {
+			t.Errorf("%s export decodes to %d albums, want 3", format, n)
+		}
+	}
+}
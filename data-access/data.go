@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"time"
+
+	"example.com/albums/albumdb"
+	"example.com/money"
 
 	"github.com/go-sql-driver/mysql"
 )
@@ -14,120 +21,159 @@ Run:
 go get .
 C:\Users\you\data-access> set DBUSER=username
 C:\Users\you\data-access> set DBPASS=password
+go run . migrate up         // create the album table and seed it
+go run .
+go run . migrate status     // or: migrate down [N], migrate redo
+go run . migrate baseline 2 // adopt a database made with the old create-tables.sql
+go run . import -format csv albums.csv   // csv, jsonl or json; -batch N rows per transaction
+go run . export -format jsonl > albums.jsonl
+
+Without a MySQL server, use SQLite (a file, recordings.db by default):
+C:\Users\you\data-access> set DB_DRIVER=sqlite3
+go run . migrate up
 go run .
+
+DB_DSN overrides the data source name of either driver, and
+DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME size the pool.
+
+A database whose album table was created before the migrations, with the
+create-tables.sql this program used to ship, already has what 0001 and 0002
+would create: migrate baseline 2 records them as applied without running
+them, and migrate up then applies the rest.
+
+Migration 0003 makes each title and artist unique. On a database that
+already has an album twice it fails and lists the ids of each copy; delete
+or rename all but one, then run migrate up again.
 */
 
+// Album is a row of the album table. The db tags name its columns for ScanAll.
 type Album struct {
-	ID     int64
-	Title  string
-	Artist string
-	Price  float32
+	ID     int64        `db:"id"`
+	Title  string       `db:"title"`
+	Artist string       `db:"artist"`
+	Price  money.Amount `db:"price"` // in USD
 }
 
 var db *sql.DB //database handle.
 
 func main() {
-	// Capture connection properties.
-	cfg := mysql.NewConfig()
-	cfg.User = os.Getenv("DBUSER")
-	cfg.Passwd = os.Getenv("DBPASS")
-	cfg.Net = "tcp"
-	cfg.Addr = "127.0.0.1:3306"
-	cfg.DBName = "recordings"
-
 	// Get a database handle.
+	var d albumdb.Dialect
 	var err error
-	db, err = sql.Open("mysql", cfg.FormatDSN())
+	db, d, err = openDB(os.Getenv)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	pingErr := db.Ping()
-	if pingErr != nil {
-		log.Fatal(pingErr)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := albumdb.PingWithBackoff(ctx, db); err != nil {
+		log.Fatal(err)
+	}
+
+	albums := NewAlbumRepository(db, d)
+
+	// import and export may take longer than ctx allows, so they get a
+	// context of their own that only Ctrl+C cancels.
+	if len(os.Args) > 1 && (os.Args[1] == "import" || os.Args[1] == "export") {
+		bulkCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		run := runImport
+		if os.Args[1] == "export" {
+			run = runExport // writes to stdout, so it doesn't print "Connected!"
+		}
+		if err := run(bulkCtx, albums, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 	fmt.Println("Connected!")
 
-	albums, err := albumsByArtist("John Coltrane")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, db, d, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	found, err := albums.List(ctx, AlbumFilter{Artist: "John Coltrane"})
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Printf("Albums found: %v\n", albums)
+	fmt.Printf("Albums found: %v\n", found)
 
 	// Hard-code ID 2 here to test the query.
-	alb, err := albumByID(2)
+	alb, err := albums.Get(ctx, 2)
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Printf("Album found: %v\n", alb)
 
-	albID, err := addAlbum(Album{
-		Title:  "The Modern Sound of Betty Carter",
-		Artist: "Betty Carter",
-		Price:  49.99,
+	// Add an album and fix its price in one transaction.
+	var albID int64
+	err = albums.WithTx(ctx, func(tx *AlbumRepository) error {
+		var err error
+		albID, err = tx.Create(ctx, Album{
+			Title:  "The Modern Sound of Betty Carter",
+			Artist: "Betty Carter",
+			Price:  money.MustParse("49.99", money.USD),
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Update(ctx, Album{ID: albID, Title: "The Modern Sound of Betty Carter", Artist: "Betty Carter", Price: money.MustParse("44.99", money.USD)})
 	})
-	if err != nil {
+	switch {
+	case errors.Is(err, ErrDuplicateAlbum):
+		fmt.Println("Album was added by an earlier run")
+	case err != nil:
 		log.Fatal(err)
+	default:
+		fmt.Printf("ID of added album: %v\n", albID)
 	}
-	fmt.Printf("ID of added album: %v\n", albID)
-}
 
-// albumsByArtist queries for albums that have the specified artist name.
-func albumsByArtist(name string) ([]Album, error) {
-	// An albums slice to hold data from returned rows.
-	var albums []Album
-	// run sql query. name provide a place for you to specify the values for parameters in your SQL statement.
-	// By separating the SQL statement from parameter values, you enable the database/sql package to send the values separate from the SQL text, removing any SQL injection risk.
-	rows, err := db.Query("SELECT * FROM album WHERE artist = ?", name)
+	n, err := albums.Count(ctx, AlbumFilter{})
 	if err != nil {
-		return nil, fmt.Errorf("albumsByArtist %q: %v", name, err)
-	}
-	defer rows.Close() // release connection when the function exits.
-	// Loop through rows, using Scan to assign column data to struct fields.
-	for rows.Next() {
-		var alb Album
-		// if 初始化语句; 条件 语法，初始化声明的变量err 作用域仅限于 if 块内
-		// 将当前行的各列值 按顺序 赋值给变量的地址；
-		// 注意：必须传指针（&alb.ID 等），否则无法写入；
-		// 列顺序必须与 SQL 查询字段顺序一致（这里是 SELECT *，所以是表定义顺序）。
-		if err := rows.Scan(&alb.ID, &alb.Title, &alb.Artist, &alb.Price); err != nil {
-			return nil, fmt.Errorf("albumsByArtist %q: %v", name, err)
-		}
-		albums = append(albums, alb) // append alb to albums slice
-	}
-	// rows.Next() 只是控制循环，但它不会暴露最终的错误；
-	// 循环结束后，必须调用 rows.Err() 来检查
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("albumsByArtist %q: %v", name, err)
+		log.Fatal(err)
 	}
-	return albums, nil
+	fmt.Printf("Albums in total: %d\n", n)
 }
 
-// albumByID queries for the album with the specified ID.
-func albumByID(id int64) (Album, error) {
-	// An album to hold data from the returned row.
-	var alb Album
-
-	row := db.QueryRow("SELECT * FROM album WHERE id = ?", id)
-	if err := row.Scan(&alb.ID, &alb.Title, &alb.Artist, &alb.Price); err != nil {
-		if err == sql.ErrNoRows {
-			return alb, fmt.Errorf("albumsById %d: no such album", id)
-		}
-		return alb, fmt.Errorf("albumsById %d: %v", id, err)
+// openDB opens the database named by the DB_DRIVER (mysql or sqlite3,
+// default mysql) and DB_DSN environment variables, read through getenv.
+// Without DB_DSN, MySQL connects to recordings on localhost as DBUSER and
+// SQLite opens recordings.db in the current directory. The pool is sized
+// by albumdb.PoolFromEnv.
+func openDB(getenv func(string) string) (*sql.DB, albumdb.Dialect, error) {
+	driver := getenv("DB_DRIVER")
+	if driver == "" {
+		driver = "mysql"
 	}
-	return alb, nil
-}
-
-// addAlbum adds the specified album to the database,
-// returning the album ID of the new entry
-func addAlbum(alb Album) (int64, error) {
-	result, err := db.Exec("INSERT INTO album (title, artist, price) VALUES (?, ?, ?)", alb.Title, alb.Artist, alb.Price)
+	pool, err := albumdb.PoolFromEnv(getenv)
 	if err != nil {
-		return 0, fmt.Errorf("addAlbum: %v", err)
+		return nil, nil, err
 	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("addAlbum: %v", err)
+
+	dsn := getenv("DB_DSN")
+	switch {
+	case dsn != "":
+	case driver == "mysql":
+		// Capture connection properties.
+		cfg := mysql.NewConfig()
+		cfg.User = getenv("DBUSER")
+		cfg.Passwd = getenv("DBPASS")
+		cfg.Net = "tcp"
+		cfg.Addr = "127.0.0.1:3306"
+		cfg.DBName = "recordings"
+		cfg.ClientFoundRows = true // report matched rather than changed rows, see AlbumRepository.Update
+		cfg.ParseTime = true       // scan DATETIME/TIMESTAMP columns into time.Time
+		dsn = cfg.FormatDSN()
+	case driver == "sqlite3":
+		// _txlock=immediate takes the write lock at BEGIN, so two writers
+		// wait on busy_timeout instead of failing midway with SQLITE_BUSY.
+		dsn = "file:recordings.db?_busy_timeout=5000&_txlock=immediate&_foreign_keys=on"
 	}
-	return id, nil
+
+	return albumdb.Open(driver, dsn, pool)
 }
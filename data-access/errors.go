@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"example.com/money"
+)
+
+// Errors returned by AlbumRepository, wrapped with the operation and ID.
+// Test for them with errors.Is.
+var (
+	// ErrAlbumNotFound means no album has the requested ID.
+	ErrAlbumNotFound = errors.New("no such album")
+	// ErrDuplicateAlbum means another album has the same title and artist.
+	ErrDuplicateAlbum = errors.New("album already exists")
+)
+
+// ValidationError is returned by Create and Update for an album that breaks
+// a rule, before it reaches the database. Fields maps each rejected field
+// to the reason, e.g. "Title": "is required".
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, field := range []string{"Title", "Artist", "Price"} {
+		if reason, ok := e.Fields[field]; ok {
+			reasons = append(reasons, field+" "+reason)
+		}
+	}
+	return "invalid album: " + strings.Join(reasons, "; ")
+}
+
+// validate returns a *ValidationError if alb can't be stored, or nil.
+func (alb Album) validate() error {
+	fields := map[string]string{}
+	if strings.TrimSpace(alb.Title) == "" {
+		fields["Title"] = "is required"
+	}
+	if strings.TrimSpace(alb.Artist) == "" {
+		fields["Artist"] = "is required"
+	}
+	if alb.Price.IsNegative() {
+		fields["Price"] = "must not be negative"
+	}
+	if c := alb.Price.Currency(); c != "" && c != money.USD {
+		fields["Price"] = "must be in USD"
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
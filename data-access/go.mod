@@ -2,6 +2,19 @@ module example/data-access
 
 go 1.25.0
 
-require github.com/go-sql-driver/mysql v1.9.3
+require (
+	example.com/albums v0.0.0-00010101000000-000000000000
+	example.com/money v0.0.0-00010101000000-000000000000
+	github.com/go-sql-driver/mysql v1.9.3
+)
 
-require filippo.io/edwards25519 v1.1.0 // indirect
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/mattn/go-sqlite3 v1.14.32 // indirect
+)
+
+// shared with web-service-gin and moduleDemo
+replace example.com/money => ../money
+
+// shared with web-service-gin
+replace example.com/albums => ../albums
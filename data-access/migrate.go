@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"example.com/albums/albumdb"
+)
+
+// runMigrate implements `go run . migrate up|down [N]|status|redo|baseline N`.
+func runMigrate(ctx context.Context, db *sql.DB, d albumdb.Dialect, args []string) error {
+	m, err := albumdb.NewMigrator(db, d)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [N]|status|redo|baseline N")
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, mig := range done {
+			fmt.Printf("applied  %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("already up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: %q is not a positive number of steps", args[1])
+			}
+		}
+		done, err := m.Down(ctx, steps)
+		for _, mig := range done {
+			fmt.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "redo":
+		mig, err := m.Redo(ctx)
+		if err == nil {
+			fmt.Printf("redone   %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "baseline":
+		if len(args) < 2 {
+			return errors.New("usage: migrate baseline N")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("migrate baseline: %q is not a version", args[1])
+		}
+		done, err := m.Baseline(ctx, version)
+		for _, mig := range done {
+			fmt.Printf("recorded %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+		return err
+	}
+	return fmt.Errorf("migrate: unknown command %q, want up, down, status, redo or baseline", args[0])
+}
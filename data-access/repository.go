@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"example.com/albums/albumdb"
+)
+
+// AlbumRepository reads and writes the album table. Its errors wrap
+// ErrAlbumNotFound, ErrDuplicateAlbum, a *ValidationError or the driver's
+// error with %w, so callers (and WithTx) can inspect them with errors.Is/As.
+type AlbumRepository struct {
+	db *sql.DB         // nil for a repository bound to a transaction
+	q  albumdb.Querier // db or the transaction, so the same code runs on either
+	d  albumdb.Dialect
+}
+
+// NewAlbumRepository returns an AlbumRepository using db, which speaks d.
+func NewAlbumRepository(db *sql.DB, d albumdb.Dialect) *AlbumRepository {
+	return &AlbumRepository{db: db, q: db, d: d}
+}
+
+// AlbumFilter narrows List and Count. The zero value matches every album.
+type AlbumFilter struct {
+	Artist string
+}
+
+// where returns the WHERE clause and its arguments for f.
+func (f AlbumFilter) where() (string, []any) {
+	if f.Artist == "" {
+		return "", nil
+	}
+	// ? is a placeholder: the value is sent separately from the SQL text,
+	// which removes any SQL injection risk.
+	return " WHERE artist = ?", []any{f.Artist}
+}
+
+// List returns the albums matching f, ordered by ID.
+func (r *AlbumRepository) List(ctx context.Context, f AlbumFilter) ([]Album, error) {
+	where, args := f.where()
+	rows, err := r.q.QueryContext(ctx, albumdb.Bind(r.d, "SELECT id, title, artist, price FROM album"+where+" ORDER BY id"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("List %+v: %w", f, err)
+	}
+	albums, err := ScanAll[Album](rows) // closes rows, releasing the connection
+	if err != nil {
+		return nil, fmt.Errorf("List %+v: %w", f, err)
+	}
+	return albums, nil
+}
+
+// Each calls fn with every album matching f, ordered by ID. Rows are read
+// one at a time, so memory use stays flat however large the table is.
+// It stops at the first error fn returns.
+func (r *AlbumRepository) Each(ctx context.Context, f AlbumFilter, fn func(Album) error) error {
+	where, args := f.where()
+	rows, err := r.q.QueryContext(ctx, albumdb.Bind(r.d, "SELECT id, title, artist, price FROM album"+where+" ORDER BY id"), args...)
+	if err != nil {
+		return fmt.Errorf("Each %+v: %w", f, err)
+	}
+	if err := ScanEach(rows, fn); err != nil {
+		return fmt.Errorf("Each %+v: %w", f, err)
+	}
+	return nil
+}
+
+// Count returns the number of albums matching f.
+func (r *AlbumRepository) Count(ctx context.Context, f AlbumFilter) (int64, error) {
+	where, args := f.where()
+	var n int64
+	if err := r.q.QueryRowContext(ctx, albumdb.Bind(r.d, "SELECT COUNT(*) FROM album"+where), args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("Count %+v: %w", f, err)
+	}
+	return n, nil
+}
+
+// Get returns the album with the specified ID.
+func (r *AlbumRepository) Get(ctx context.Context, id int64) (Album, error) {
+	rows, err := r.q.QueryContext(ctx, albumdb.Bind(r.d, "SELECT id, title, artist, price FROM album WHERE id = ?"), id)
+	if err != nil {
+		return Album{}, fmt.Errorf("Get %d: %w", id, err)
+	}
+	albums, err := ScanAll[Album](rows)
+	if err != nil {
+		return Album{}, fmt.Errorf("Get %d: %w", id, err)
+	}
+	if len(albums) == 0 {
+		return Album{}, fmt.Errorf("Get %d: %w", id, ErrAlbumNotFound)
+	}
+	return albums[0], nil
+}
+
+// Create adds alb to the database and returns the ID of the new entry.
+func (r *AlbumRepository) Create(ctx context.Context, alb Album) (int64, error) {
+	if err := alb.validate(); err != nil {
+		return 0, fmt.Errorf("Create: %w", err)
+	}
+	id, err := r.d.InsertID(ctx, r.q, albumdb.Bind(r.d, "INSERT INTO album (title, artist, price) VALUES (?, ?, ?)"), alb.Title, alb.Artist, alb.Price)
+	if err != nil {
+		return 0, fmt.Errorf("Create: %w", r.mapError(err))
+	}
+	return id, nil
+}
+
+// Update overwrites the title, artist and price of the album with alb.ID.
+//
+// MySQL reports an UPDATE that changes nothing as 0 rows affected, so the
+// connection must set ClientFoundRows for an unchanged album not to look
+// missing.
+func (r *AlbumRepository) Update(ctx context.Context, alb Album) error {
+	if err := alb.validate(); err != nil {
+		return fmt.Errorf("Update %d: %w", alb.ID, err)
+	}
+	result, err := r.q.ExecContext(ctx, albumdb.Bind(r.d, "UPDATE album SET title = ?, artist = ?, price = ? WHERE id = ?"), alb.Title, alb.Artist, alb.Price, alb.ID)
+	if err != nil {
+		return fmt.Errorf("Update %d: %w", alb.ID, r.mapError(err))
+	}
+	return checkAffected(result, "Update", alb.ID)
+}
+
+// Delete removes the album with the specified ID.
+func (r *AlbumRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.q.ExecContext(ctx, albumdb.Bind(r.d, "DELETE FROM album WHERE id = ?"), id)
+	if err != nil {
+		return fmt.Errorf("Delete %d: %w", id, err)
+	}
+	return checkAffected(result, "Delete", id)
+}
+
+// checkAffected turns a statement that touched no row into ErrAlbumNotFound.
+func checkAffected(result sql.Result, op string, id int64) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s %d: %w", op, id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %d: %w", op, id, ErrAlbumNotFound)
+	}
+	return nil
+}
+
+// mapError replaces a unique key violation with ErrDuplicateAlbum. Other
+// driver errors are kept, WithTx needs them to spot a deadlock.
+func (r *AlbumRepository) mapError(err error) error {
+	if r.d.Duplicate(err) {
+		return fmt.Errorf("%w (%v)", ErrDuplicateAlbum, err)
+	}
+	return err
+}
+
+// maxTxAttempts is how many times WithTx runs a transaction that keeps
+// failing with a retryable error.
+const maxTxAttempts = 3
+
+// WithTx runs fn in a transaction, passing it a repository bound to that
+// transaction. The transaction is committed if fn returns nil and rolled
+// back otherwise. When the database aborts it with an error the dialect
+// calls retryable (a MySQL deadlock, error 1213, or a busy SQLite file) the
+// whole transaction is retried, so fn must be safe to run again.
+func (r *AlbumRepository) WithTx(ctx context.Context, fn func(tx *AlbumRepository) error) error {
+	if r.db == nil {
+		return errors.New("WithTx: repository is already in a transaction")
+	}
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = r.runTx(ctx, fn)
+		if !r.d.Retryable(err) {
+			return err
+		}
+		// Back off a little so the transaction that won can finish.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * 20 * time.Millisecond):
+		}
+	}
+	return fmt.Errorf("WithTx: gave up after %d attempts: %w", maxTxAttempts, err)
+}
+
+func (r *AlbumRepository) runTx(ctx context.Context, fn func(tx *AlbumRepository) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(&AlbumRepository{q: tx, d: r.d}); err != nil {
+		tx.Rollback() // the error from fn is the one worth returning
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"example.com/albums/albumdb"
+	"example.com/money"
+)
+
+// errDeadlock stands in for a deadlock, which a real database can't be made
+// to report on demand.
+var errDeadlock = errors.New("deadlock found when trying to get lock")
+
+// deadlockDialect is SQLite, except that errDeadlock is retryable.
+type deadlockDialect struct{ albumdb.Dialect }
+
+func (d deadlockDialect) Retryable(err error) bool {
+	return errors.Is(err, errDeadlock) || d.Dialect.Retryable(err)
+}
+
+func TestWithTxRetries(t *testing.T) {
+	ctx := context.Background()
+	sqlite, _ := openTestDB(t)
+	albums := NewAlbumRepository(sqlite.db, deadlockDialect{albumdb.SQLite})
+
+	// tx adds an album and then fails with errDeadlock the first failures times.
+	tx := func(title string, failures int, calls *int) func(*AlbumRepository) error {
+		return func(tx *AlbumRepository) error {
+			*calls++
+			if _, err := tx.Create(ctx, Album{Title: title, Artist: "Retry", Price: money.MustParse("1", money.USD)}); err != nil {
+				return err
+			}
+			if *calls <= failures {
+				return errDeadlock
+			}
+			return nil
+		}
+	}
+
+	calls := 0
+	if err := albums.WithTx(ctx, tx("Second time lucky", maxTxAttempts-1, &calls)); err != nil || calls != maxTxAttempts {
+		t.Errorf("WithTx failing %d times = %v after %d calls, want nil after %d", maxTxAttempts-1, err, calls, maxTxAttempts)
+	}
+	if n, _ := albums.Count(ctx, AlbumFilter{Artist: "Retry"}); n != 1 {
+		t.Errorf("after the retries %d albums were saved, want 1", n)
+	}
+
+	calls = 0
+	err := albums.WithTx(ctx, tx("Never", maxTxAttempts, &calls))
+	if !errors.Is(err, errDeadlock) || calls != maxTxAttempts {
+		t.Errorf("WithTx always failing = %v after %d calls, want errDeadlock after %d", err, calls, maxTxAttempts)
+	}
+	if n, _ := albums.Count(ctx, AlbumFilter{Artist: "Retry"}); n != 1 {
+		t.Errorf("a transaction that never succeeded saved %d albums", n-1)
+	}
+
+	// Other errors aren't retried.
+	calls = 0
+	errBoom := errors.New("boom")
+	err = albums.WithTx(ctx, func(*AlbumRepository) error { calls++; return errBoom })
+	if !errors.Is(err, errBoom) || calls != 1 {
+		t.Errorf("WithTx with a non-retryable error = %v after %d calls, want it after 1", err, calls)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ScanAll reads every row of rows into a T, matching columns to the fields
+// of T by their `db:"column"` tags, and closes rows. Unlike a positional
+// rows.Scan it doesn't care about the column order of the query.
+//
+// A column that can be NULL needs a field that can hold it, such as
+// sql.NullString or *string. A column without a field is an error, so a
+// typo in a tag doesn't silently leave a field empty.
+//
+//	type Album struct {
+//		ID    int64  `db:"id"`
+//		Title string `db:"title"`
+//	}
+//	albums, err := ScanAll[Album](rows)
+func ScanAll[T any](rows *sql.Rows) ([]T, error) {
+	var all []T
+	err := ScanEach(rows, func(v T) error {
+		all = append(all, v)
+		return nil
+	})
+	return all, err
+}
+
+// ScanEach is ScanAll for results too large to hold in memory: it calls fn
+// with each row as soon as it is read, and stops at the first error fn
+// returns.
+func ScanEach[T any](rows *sql.Rows, fn func(T) error) error {
+	defer rows.Close()
+
+	t := reflect.TypeFor[T]()
+	fields, err := fieldsOf(t)
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	indexes := make([][]int, len(columns))
+	for i, col := range columns {
+		index, ok := fields[col]
+		if !ok {
+			return fmt.Errorf("ScanAll: column %q has no db tag in %s", col, t)
+		}
+		indexes[i] = index
+	}
+
+	dest := make([]any, len(columns))
+	for rows.Next() {
+		var v T
+		rv := reflect.ValueOf(&v).Elem()
+		for i, index := range indexes {
+			if dest[i], err = fieldAddr(rv, index); err != nil {
+				return err
+			}
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// fieldAddr returns a pointer to the field of v at index. Unlike
+// v.FieldByIndex, which panics on a nil embedded *struct, it allocates the
+// embedded structs on the way.
+func fieldAddr(v reflect.Value, index []int) (any, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return nil, fmt.Errorf("ScanAll: can't allocate the unexported embedded %s", v.Type())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v.Addr().Interface(), nil
+}
+
+// fieldPlans caches fieldsOf by type, so reflection over the struct's tags
+// happens once per type rather than once per query.
+var fieldPlans sync.Map // reflect.Type -> map[string][]int
+
+// fieldsOf maps the db tag of each field of struct type t, including the
+// fields of embedded structs, to the field's index.
+func fieldsOf(t reflect.Type) (map[string][]int, error) {
+	if plan, ok := fieldPlans.Load(t); ok {
+		return plan.(map[string][]int), nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ScanAll: %s is not a struct", t)
+	}
+	plan := map[string][]int{}
+	for _, f := range reflect.VisibleFields(t) {
+		col := f.Tag.Get("db")
+		if col == "" || col == "-" || !f.IsExported() {
+			continue
+		}
+		if _, dup := plan[col]; dup {
+			return nil, fmt.Errorf("ScanAll: two fields of %s are tagged db:%q", t, col)
+		}
+		plan[col] = f.Index
+	}
+	fieldPlans.Store(t, plan)
+	return plan, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+type scanBase struct {
+	ID int64 `db:"id"`
+}
+
+type scanRow struct {
+	scanBase
+	Name    string         `db:"name"`
+	Nick    sql.NullString `db:"nick"`
+	Score   *float64       `db:"score"`
+	Ignored string
+}
+
+// ScanAudit is embedded by pointer, which ScanAll has to allocate.
+type ScanAudit struct {
+	By string `db:"by"`
+}
+
+type scanAuditedRow struct {
+	*ScanAudit
+	ID int64 `db:"id"`
+}
+
+func TestScanAll(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Columns in a different order from the fields, with NULLs.
+	const query = `SELECT 'Gerry' AS name, NULL AS score, 2 AS id, 'Jeru' AS nick
+		UNION ALL SELECT 'Sarah', 9.5, 3, NULL`
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ScanAll[scanRow](rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d rows, want 2", len(got))
+	}
+	if r := got[0]; r.ID != 2 || r.Name != "Gerry" || r.Nick.String != "Jeru" || r.Score != nil {
+		t.Errorf("row 0 = %+v", r)
+	}
+	if r := got[1]; r.ID != 3 || r.Nick.Valid || r.Score == nil || *r.Score != 9.5 {
+		t.Errorf("row 1 = %+v", r)
+	}
+
+	rows, err = db.Query("SELECT 1 AS id, 'gerry' AS by")
+	if err != nil {
+		t.Fatal(err)
+	}
+	audited, err := ScanAll[scanAuditedRow](rows)
+	if err != nil || len(audited) != 1 || audited[0].ScanAudit == nil || audited[0].By != "gerry" {
+		t.Errorf("ScanAll into an embedded *struct = %+v, %v", audited, err)
+	}
+
+	rows, err = db.Query("SELECT 1 AS id, 'x' AS unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ScanAll[scanRow](rows); err == nil || !strings.Contains(err.Error(), `"unknown"`) {
+		t.Errorf("ScanAll with an unmapped column error = %v, want one naming the column", err)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"example.com/albums/albumdb"
+	"example.com/money"
+)
+
+// openTestDB returns a migrated SQLite database in a temporary directory.
+func openTestDB(t *testing.T) (*AlbumRepository, *albumdb.Migrator) {
+	t.Helper()
+	env := map[string]string{
+		"DB_DRIVER": "sqlite3",
+		"DB_DSN":    "file:" + filepath.Join(t.TempDir(), "recordings.db") + "?_busy_timeout=5000&_txlock=immediate",
+	}
+	db, d, err := openDB(func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := albumdb.NewMigrator(db, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewAlbumRepository(db, d), m
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	albums, m := openTestDB(t)
+
+	if done, err := m.Up(ctx); err != nil || len(done) != 0 {
+		t.Errorf("second Up = %v, %v; want nothing to do", done, err)
+	}
+	if n, err := albums.Count(ctx, AlbumFilter{}); err != nil || n != 3 {
+		t.Errorf("Count after seeding = %d, %v; want 3", n, err)
+	}
+
+	if _, err := m.Redo(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := albums.Count(ctx, AlbumFilter{}); n != 3 {
+		t.Errorf("Count after redo = %d, want 3", n)
+	}
+
+	if done, err := m.Down(ctx, 2); err != nil || len(done) != 2 || done[1].Name != "seed_albums" {
+		t.Fatalf("Down(2) = %v, %v; want unique_album and seed_albums", done, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Errorf("Status = %+v, want create_album applied and seed_albums pending", statuses)
+	}
+}
+
+// TestUniqueAlbumMigration checks that 0003_unique_album refuses albums
+// added twice before it, naming them, rather than deleting any.
+func TestUniqueAlbumMigration(t *testing.T) {
+	ctx := context.Background()
+	albums, m := openTestDB(t)
+	if done, err := m.Down(ctx, 1); err != nil || len(done) != 1 || done[0].Name != "unique_album" {
+		t.Fatalf("Down(1) = %v, %v; want unique_album", done, err)
+	}
+	id, err := albums.Create(ctx, Album{Title: "Blue Train", Artist: "John Coltrane", Price: money.MustParse("1", money.USD)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Up(ctx)
+	if !errors.Is(err, albumdb.ErrDuplicateAlbums) || !strings.Contains(err.Error(), `"Blue Train" by "John Coltrane" (ids 1,4)`) {
+		t.Fatalf("Up = %v, want ErrDuplicateAlbums naming ids 1,4", err)
+	}
+	if found, err := albums.List(ctx, AlbumFilter{Artist: "John Coltrane"}); err != nil || len(found) != 2 {
+		t.Errorf("after the failed Up, John Coltrane's albums = %v, %v; want both", found, err)
+	}
+
+	if err := albums.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 1 {
+		t.Errorf("Up after deleting the copy = %v, %v; want unique_album", done, err)
+	}
+}
+
+// TestBaseline adopts an album table made by the old create-tables.sql:
+// baseline 2 records 0001 and 0002, and Up runs only 0003, keeping the rows.
+func TestBaseline(t *testing.T) {
+	ctx := context.Background()
+	albums, m := openTestDB(t)
+	if _, err := m.Down(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE album (id INTEGER PRIMARY KEY AUTOINCREMENT, title VARCHAR(128) NOT NULL, artist VARCHAR(255) NOT NULL, price DECIMAL(5,2) NOT NULL)",
+		"INSERT INTO album (title, artist, price) VALUES ('Blue Train', 'John Coltrane', 56.99), ('Giant Steps', 'John Coltrane', 63.99)",
+	} {
+		if _, err := albums.db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := m.Baseline(ctx, 7); err == nil {
+		t.Error("Baseline(7): want error for an unknown version")
+	}
+	if done, err := m.Baseline(ctx, 2); err != nil || len(done) != 2 {
+		t.Fatalf("Baseline(2) = %v, %v; want create_album and seed_albums", done, err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 1 || done[0].Name != "unique_album" {
+		t.Fatalf("Up after Baseline = %v, %v; want unique_album", done, err)
+	}
+	if n, err := albums.Count(ctx, AlbumFilter{}); err != nil || n != 2 {
+		t.Errorf("Count = %d, %v; want the 2 existing albums", n, err)
+	}
+}
+
+func TestSQLiteRepository(t *testing.T) {
+	ctx := context.Background()
+	albums, _ := openTestDB(t)
+
+	found, err := albums.List(ctx, AlbumFilter{Artist: "John Coltrane"})
+	if err != nil || len(found) != 1 || found[0].Title != "Blue Train" {
+		t.Fatalf("List John Coltrane = %v, %v", found, err)
+	}
+
+	id, err := albums.Create(ctx, Album{Title: "The Modern Sound of Betty Carter", Artist: "Betty Carter", Price: money.MustParse("49.99", money.USD)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 {
+		t.Errorf("Create returned ID %d, want 4", id)
+	}
+	// Unchanged values still count as found, as with ClientFoundRows on MySQL.
+	alb := Album{ID: id, Title: "The Modern Sound of Betty Carter", Artist: "Betty Carter", Price: money.MustParse("49.99", money.USD)}
+	if err := albums.Update(ctx, alb); err != nil {
+		t.Errorf("Update with the same values: %v", err)
+	}
+	if err := albums.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := albums.Get(ctx, id); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrAlbumNotFound", err)
+	}
+	if err := albums.Update(ctx, alb); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("Update after Delete error = %v, want ErrAlbumNotFound", err)
+	}
+
+	_, err = albums.Create(ctx, Album{Title: "Blue Train", Artist: "John Coltrane", Price: money.MustParse("56.99", money.USD)})
+	if !errors.Is(err, ErrDuplicateAlbum) {
+		t.Errorf("Create of a seeded album error = %v, want ErrDuplicateAlbum", err)
+	}
+	var invalid *ValidationError
+	_, err = albums.Create(ctx, Album{Artist: "Nobody", Price: money.MustParse("-1", money.USD)})
+	if !errors.As(err, &invalid) || len(invalid.Fields) != 2 {
+		t.Errorf("Create of an invalid album error = %v, want *ValidationError on Title and Price", err)
+	}
+
+	// A failing transaction leaves nothing behind.
+	errBoom := errors.New("boom")
+	err = albums.WithTx(ctx, func(tx *AlbumRepository) error {
+		if _, err := tx.Create(ctx, Album{Title: "Rolled back", Artist: "Nobody", Price: money.MustParse("1", money.USD)}); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("WithTx = %v, want %v", err, errBoom)
+	}
+	if n, _ := albums.Count(ctx, AlbumFilter{Artist: "Nobody"}); n != 0 {
+		t.Errorf("rolled back album was saved")
+	}
+}
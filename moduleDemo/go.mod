@@ -2,4 +2,11 @@ module moduleDemo
 
 go 1.25.0
 
-require github.com/mattn/go-sqlite3 v1.14.32 // indirect
+require (
+	example.com/money v0.0.0-00010101000000-000000000000
+	github.com/mattn/go-sqlite3 v1.14.32
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+// shared with data-access and web-service-gin
+replace example.com/money => ../money
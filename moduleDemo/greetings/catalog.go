@@ -0,0 +1,142 @@
+package greetings
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Messages is the catalogue HelloIn greets from: the ones in locales, with
+// English as the last resort.
+var Messages = mustLoad(locales, "locales", "en")
+
+// ErrBadCatalog is wrapped by the errors LoadCatalog returns for a catalogue
+// file it can't use.
+var ErrBadCatalog = errors.New("bad message catalogue")
+
+// Catalog holds the greeting formats of a set of languages, one catalogue
+// file per language, and greets in each with a Greeter that picks among its
+// formats at random.
+type Catalog struct {
+	tags     []language.Tag // tags[0] is the default language
+	greeters map[language.Tag]*Greeter
+	matcher  language.Matcher
+}
+
+// catalogFile is the content of a catalogue file, e.g. locales/zh-CN.json.
+type catalogFile struct {
+	// Formats are the greetings to pick from, each with one %v for the name.
+	Formats []string `json:"formats"`
+}
+
+// LoadCatalog loads every <language>.json file in dir of fsys, such as
+// "zh-CN.json" or "ja.json". def is the language to fall back to when none
+// that is asked for can be matched; it must be one of the files.
+func LoadCatalog(fsys fs.FS, dir, def string) (*Catalog, error) {
+	defTag, err := language.Parse(def)
+	if err != nil {
+		return nil, err
+	}
+	names, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{tags: []language.Tag{defTag}, greeters: map[language.Tag]*Greeter{}}
+	for _, name := range names {
+		tag, formats, err := loadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if _, ok := c.greeters[tag]; ok {
+			return nil, fmt.Errorf("%s: %w: %v has another file", name, ErrBadCatalog, tag)
+		}
+		// Not NewGreeter, whose defaults come from Messages: loadFile has
+		// checked the formats.
+		c.greeters[tag] = &Greeter{formats: formats}
+		if tag != defTag {
+			c.tags = append(c.tags, tag)
+		}
+	}
+	if _, ok := c.greeters[defTag]; !ok {
+		return nil, fmt.Errorf("%w: no file for the default language %v in %s", ErrBadCatalog, defTag, dir)
+	}
+	c.matcher = language.NewMatcher(c.tags)
+	return c, nil
+}
+
+func mustLoad(fsys fs.FS, dir, def string) *Catalog {
+	c, err := LoadCatalog(fsys, dir, def)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func loadFile(fsys fs.FS, name string) (language.Tag, []string, error) {
+	tag, err := language.Parse(strings.TrimSuffix(path.Base(name), ".json"))
+	if err != nil {
+		return language.Und, nil, fmt.Errorf("%w: the file name isn't a language: %v", ErrBadCatalog, err)
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return language.Und, nil, err
+	}
+	var f catalogFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return language.Und, nil, fmt.Errorf("%w: %v", ErrBadCatalog, err)
+	}
+	if len(f.Formats) == 0 {
+		return language.Und, nil, fmt.Errorf("%w: no formats", ErrBadCatalog)
+	}
+	for _, format := range f.Formats {
+		if err := checkFormat(format); err != nil {
+			return language.Und, nil, fmt.Errorf("%w: %v", ErrBadCatalog, err)
+		}
+	}
+	return tag, f.Formats, nil
+}
+
+// checkFormat checks that format has one %v, for the name, and no other
+// verb.
+func checkFormat(format string) error {
+	if strings.Count(format, "%v") != 1 || strings.Count(format, "%") != 1 {
+		return fmt.Errorf("format %q must have one %%v and no other verb", format)
+	}
+	return nil
+}
+
+// Languages returns the languages of the catalogue, the default first.
+func (c *Catalog) Languages() []language.Tag {
+	return append([]language.Tag(nil), c.tags...)
+}
+
+// Match returns the language of the catalogue that best suits lang, which
+// is a language tag such as "zh-CN" or an Accept-Language header such as
+// "ja-JP, zh;q=0.8, en;q=0.5".
+//
+// The languages in lang are tried by preference. Each also falls back to
+// the closest language the catalogue has: zh-SG and plain zh to zh-CN,
+// zh-HK to zh-TW, en-GB to en and so on. If nothing is close enough, or
+// lang can't be parsed, Match returns the default language.
+func (c *Catalog) Match(lang string) language.Tag {
+	_, i := language.MatchStrings(c.matcher, lang)
+	return c.tags[i]
+}
+
+// Hello returns a greeting for the named person in the language that best
+// suits lang; see Match.
+func (c *Catalog) Hello(lang, name string) (string, error) {
+	return c.greeters[c.Match(lang)].Hello(name)
+}
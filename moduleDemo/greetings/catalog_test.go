@@ -0,0 +1,74 @@
+package greetings
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/text/language"
+)
+
+func TestHelloIn(t *testing.T) {
+	tests := []struct {
+		lang string
+		want language.Tag
+	}{
+		{"zh-CN", language.MustParse("zh-CN")},
+		{"zh", language.MustParse("zh-CN")},
+		{"zh-HK", language.MustParse("zh-TW")},
+		{"ja-JP", language.Japanese},
+		{"en-GB", language.English},
+		{"fr, ja;q=0.5", language.Japanese}, // no French, so the next choice
+		{"zh-CN,zh;q=0.9,en;q=0.8", language.MustParse("zh-CN")},
+		{"fr", language.English},
+		{"", language.English},
+		{"not a language", language.English},
+	}
+	for _, tt := range tests {
+		if got := Messages.Match(tt.lang); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.lang, got, tt.want)
+		}
+		msg, err := HelloIn(tt.lang, "Gladys")
+		if err != nil || !strings.Contains(msg, "Gladys") {
+			t.Errorf("HelloIn(%q, \"Gladys\") = %q, %v", tt.lang, msg, err)
+		}
+	}
+	if msg, err := HelloIn("ja", ""); msg != "" || err == nil {
+		t.Errorf(`HelloIn("ja", "") = %q, %v, want "", error`, msg, err)
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	fsys := fstest.MapFS{
+		"l/de.json": {Data: []byte(`{"formats": ["Hallo, %v!"]}`)},
+		"l/ja.json": {Data: []byte(`{"formats": ["%vさん、こんにちは！"]}`)},
+	}
+	c, err := LoadCatalog(fsys, "l", "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Languages(); len(got) != 2 || got[0] != language.German {
+		t.Errorf("Languages() = %v, want German first", got)
+	}
+	if msg, _ := c.Hello("en", "Gladys"); msg != "Hallo, Gladys!" {
+		t.Errorf(`Hello("en", "Gladys") = %q, want the default language`, msg)
+	}
+
+	bad := map[string]string{
+		"no formats":   `{"formats": []}`,
+		"two verbs":    `{"formats": ["%v and %v"]}`,
+		"other verb":   `{"formats": ["%d, %v"]}`,
+		"unknown key":  `{"formats": ["Hallo, %v!"], "fallback": "en"}`,
+		"invalid json": `{"formats":`,
+	}
+	for why, data := range bad {
+		fsys := fstest.MapFS{"l/de.json": {Data: []byte(data)}}
+		if _, err := LoadCatalog(fsys, "l", "de"); !errors.Is(err, ErrBadCatalog) {
+			t.Errorf("%s: LoadCatalog = %v, want ErrBadCatalog", why, err)
+		}
+	}
+	if _, err := LoadCatalog(fsys, "l", "en"); !errors.Is(err, ErrBadCatalog) {
+		t.Errorf("LoadCatalog without the default language = %v, want ErrBadCatalog", err)
+	}
+}
@@ -1,3 +1,5 @@
 module example.com/greetings
 
 go 1.25.0
+
+require golang.org/x/text v0.29.0
@@ -0,0 +1,155 @@
+package greetings
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+)
+
+// std is the Greeter behind Hello and Hellos: English, picked at random.
+var std = mustGreeter()
+
+// Greeter greets people with one of a set of formats, chosen by its
+// strategy: at random (the default), in turn, or at random by weight. A
+// Greeter is safe for concurrent use.
+type Greeter struct {
+	formats    []string
+	weights    []int // nil unless weighted
+	roundRobin bool
+
+	mu   sync.Mutex
+	rand *rand.Rand // nil for the global source
+	next int        // the next format, for round-robin
+}
+
+// Option configures a Greeter made by NewGreeter.
+type Option func(*Greeter)
+
+// WithSource makes the Greeter draw its random numbers from src instead of
+// the global source, so that a seeded source such as rand.NewPCG(1, 2)
+// always greets the same way.
+func WithSource(src rand.Source) Option {
+	return func(g *Greeter) {
+		g.rand = rand.New(src)
+	}
+}
+
+// WithFormats sets the formats to greet with, each with one %v for the
+// name. The default is Messages' English.
+func WithFormats(formats ...string) Option {
+	formats = slices.Clone(formats)
+	return func(g *Greeter) {
+		g.formats = formats
+	}
+}
+
+// WithLanguage sets the formats to those of the language of Messages that
+// best suits lang; see Catalog.Match.
+func WithLanguage(lang string) Option {
+	return func(g *Greeter) {
+		g.formats = Messages.greeters[Messages.Match(lang)].formats
+	}
+}
+
+// WithRoundRobin makes the Greeter use its formats in turn rather than at
+// random.
+func WithRoundRobin() Option {
+	return func(g *Greeter) {
+		g.roundRobin, g.weights = true, nil
+	}
+}
+
+// WithWeights makes the Greeter pick formats at random by weight: with
+// weights 3, 1, the first format is used three times as often as the
+// second. It takes one weight per format.
+func WithWeights(weights ...int) Option {
+	weights = slices.Clone(weights)
+	return func(g *Greeter) {
+		g.roundRobin, g.weights = false, weights
+	}
+}
+
+// NewGreeter returns a Greeter configured by options. It fails if a format
+// doesn't have one %v, or if the weights don't suit the formats.
+func NewGreeter(options ...Option) (*Greeter, error) {
+	g := &Greeter{formats: Messages.greeters[Messages.Match("en")].formats}
+	for _, o := range options {
+		o(g)
+	}
+	if len(g.formats) == 0 {
+		return nil, errors.New("no formats")
+	}
+	for _, format := range g.formats {
+		if err := checkFormat(format); err != nil {
+			return nil, err
+		}
+	}
+	if g.weights != nil {
+		if len(g.weights) != len(g.formats) {
+			return nil, fmt.Errorf("%d weights for %d formats", len(g.weights), len(g.formats))
+		}
+		total := 0
+		for _, w := range g.weights {
+			if w < 0 {
+				return nil, fmt.Errorf("negative weight %d", w)
+			}
+			total += w
+		}
+		if total == 0 {
+			return nil, errors.New("all weights are zero")
+		}
+	}
+	return g, nil
+}
+
+func mustGreeter(options ...Option) *Greeter {
+	g, err := NewGreeter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Hello returns a greeting for the named person.
+func (g *Greeter) Hello(name string) (string, error) {
+	if name == "" {
+		return name, errors.New("empty name")
+	}
+	return fmt.Sprintf(g.format(), name), nil
+}
+
+// format picks the next format by g's strategy.
+func (g *Greeter) format() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch {
+	case g.roundRobin:
+		format := g.formats[g.next]
+		g.next = (g.next + 1) % len(g.formats)
+		return format
+	case g.weights != nil:
+		total := 0
+		for _, w := range g.weights {
+			total += w
+		}
+		n := g.intN(total)
+		for i, w := range g.weights {
+			if n < w {
+				return g.formats[i]
+			}
+			n -= w
+		}
+		panic("unreachable")
+	default:
+		return g.formats[g.intN(len(g.formats))]
+	}
+}
+
+func (g *Greeter) intN(n int) int {
+	if g.rand == nil {
+		return rand.IntN(n)
+	}
+	return g.rand.IntN(n)
+}
@@ -0,0 +1,87 @@
+package greetings
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestGreeter(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		want    []string
+	}{
+		{
+			"seeded random",
+			[]Option{WithSource(rand.NewPCG(1, 2))},
+			[]string{"Hail, Gladys! Well met!", "Great to see you, Gladys!", "Hail, Gladys! Well met!", "Hail, Gladys! Well met!"},
+		},
+		{
+			"round-robin",
+			[]Option{WithFormats("Hi, %v.", "Bye, %v."), WithRoundRobin()},
+			[]string{"Hi, Gladys.", "Bye, Gladys.", "Hi, Gladys."},
+		},
+		{
+			"weighted",
+			[]Option{WithFormats("Hi, %v.", "Bye, %v."), WithWeights(0, 1), WithSource(rand.NewPCG(1, 2))},
+			[]string{"Bye, Gladys.", "Bye, Gladys.", "Bye, Gladys."},
+		},
+		{
+			"in Japanese",
+			[]Option{WithLanguage("ja-JP"), WithRoundRobin()},
+			[]string{"こんにちは、Gladysさん。ようこそ！", "Gladysさん、お会いできてうれしいです！"},
+		},
+	}
+	for _, tt := range tests {
+		g, err := NewGreeter(tt.options...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for i, want := range tt.want {
+			if msg, err := g.Hello("Gladys"); msg != want || err != nil {
+				t.Errorf("%s: greeting %d = %q, %v, want %q", tt.name, i, msg, err, want)
+			}
+		}
+	}
+}
+
+func TestWithFormatsCopies(t *testing.T) {
+	formats := []string{"Hi, %v."}
+	g, err := NewGreeter(WithFormats(formats...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	formats[0] = "Bye, %v."
+	if msg, _ := g.Hello("Gladys"); msg != "Hi, Gladys." {
+		t.Errorf("after changing the caller's formats Hello = %q, want %q", msg, "Hi, Gladys.")
+	}
+}
+
+func TestWithWeightsCopies(t *testing.T) {
+	weights := []int{1, 0}
+	g, err := NewGreeter(WithFormats("Hi, %v.", "Bye, %v."), WithWeights(weights...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights[0], weights[1] = 0, 1
+	for range 20 {
+		if msg, _ := g.Hello("Gladys"); msg != "Hi, Gladys." {
+			t.Fatalf("after changing the caller's weights Hello = %q, want %q", msg, "Hi, Gladys.")
+		}
+	}
+}
+
+func TestNewGreeterErrors(t *testing.T) {
+	bad := map[string][]Option{
+		"no formats":       {WithFormats()},
+		"no verb":          {WithFormats("Hi!")},
+		"too few weights":  {WithFormats("Hi, %v.", "Bye, %v."), WithWeights(1)},
+		"negative weight":  {WithFormats("Hi, %v.", "Bye, %v."), WithWeights(2, -1)},
+		"all weights zero": {WithFormats("Hi, %v."), WithWeights(0)},
+	}
+	for why, options := range bad {
+		if _, err := NewGreeter(options...); err == nil {
+			t.Errorf("%s: NewGreeter succeeded", why)
+		}
+	}
+}
@@ -1,20 +1,17 @@
 package greetings
 
-import (
-	"errors"
-	"fmt"
-	"math/rand"
-)
-
-// Hello returns a greeting for the named person.
+// Hello returns a greeting for the named person, in English and picked at
+// random. Use a Greeter to choose the formats and how they are picked.
 func Hello(name string) (string, error) {
-	// If no name was given, return an error with a message.
-	if name == "" {
-		return name, errors.New("empty name")
-	}
-	// Create a message using a random format.
-	message := fmt.Sprintf(randomFormat(), name)
-	return message, nil
+	return std.Hello(name)
+}
+
+// HelloIn returns a greeting for the named person in the language of
+// Messages that best suits lang, a language tag such as "zh-CN" or an
+// Accept-Language header such as "ja, zh;q=0.8". Languages Messages
+// doesn't have fall back to the closest one it does, and then to English.
+func HelloIn(lang, name string) (string, error) {
+	return Messages.Hello(lang, name)
 }
 
 // Hellos returns a map that associates each of the named people
@@ -35,17 +32,3 @@ func Hellos(names []string) (map[string]string, error) {
 	}
 	return messages, nil
 }
-
-// randomFormat returns one of a set of greeting messages. The returned
-// message is selected at random.
-func randomFormat() string {
-	// A slice of message formats.
-	formats := []string{
-		"Hi, %v. Welcome!",
-		"Great to see you, %v!",
-		"Hail, %v! Well met!",
-	}
-
-	// Return one of the message formats selected at random.
-	return formats[rand.Intn(len(formats))]
-}
@@ -46,3 +46,18 @@ func main2() {
 	// messages to the console.
 	fmt.Println(messages)
 }
+
+func main3() {
+	log.SetPrefix("greetings: ")
+	log.SetFlags(0)
+
+	// Greet in the language the user prefers, given as a language tag
+	// or, as a browser sends it, an Accept-Language header.
+	for _, lang := range []string{"zh-CN", "ja", "fr, en;q=0.5", "zh-HK"} {
+		message, err := greetings.HelloIn(lang, "Gladys")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(lang, message)
+	}
+}
@@ -1,11 +1,36 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"moduleDemo/shopping" // 注意：这里使用模块路径（同go.mod里面) + 相对路径。 同一个module内导入包总是从module名开始
+	"moduleDemo/shopping/cart"
+	"moduleDemo/shopping/db"
 )
 
 func main() {
-	// fmt.Println(shopping.PriceCheck(4343))
-	fmt.Println(shopping.PriceCheck2(4343))
+	items, err := db.OpenSQLite("catalogue.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer items.Close()
+	shopping.Catalogue = items
+
+	fmt.Println(shopping.PriceCheck(1))
+	fmt.Println(shopping.PriceCheck(4343)) // not in the catalogue: 0 false
+	fmt.Println(shopping.PriceCheckMany(context.Background(), []int{1, 2, 3, 4343}))
+
+	c := cart.New(cart.WithTaxRate(825)) // 8.25% tax
+	if err := c.Add(1, 2); err != nil {
+		log.Fatal(err)
+	}
+	if err := c.Add(2, 1); err != nil {
+		log.Fatal(err)
+	}
+	order, err := c.Checkout()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("order: %+v\n", order.Totals())
 }
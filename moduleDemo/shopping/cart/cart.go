@@ -0,0 +1,231 @@
+// Package cart is a shopping cart priced through shopping.PriceCheck.
+//
+//	c := cart.New(cart.WithTaxRate(825), cart.WithDiscount(cart.PercentOff(10)))
+//	c.Add(1, 2)
+//	order, err := c.Checkout()
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"moduleDemo/shopping"
+
+	"example.com/money"
+)
+
+var (
+	// ErrItemNotFound means the pricer doesn't know the item (any more).
+	ErrItemNotFound = errors.New("cart: item not found")
+	// ErrInvalidQuantity means a quantity was zero or negative where it can't be.
+	ErrInvalidQuantity = errors.New("cart: invalid quantity")
+	// ErrEmptyCart means Checkout was called on a cart without lines.
+	ErrEmptyCart = errors.New("cart: cart is empty")
+)
+
+// Pricer returns the current unit price of an item, and false if there is no
+// such item. shopping.PriceCheck is the default.
+type Pricer func(itemID int) (money.Amount, bool)
+
+// Line is one item in a cart and how many of it.
+type Line struct {
+	ItemID    int
+	Quantity  int64
+	UnitPrice money.Amount
+	Total     money.Amount // UnitPrice * Quantity
+}
+
+// Cart is a shopping cart. It is safe for concurrent use.
+type Cart struct {
+	price     Pricer
+	taxRate   int64 // basis points
+	discounts []Discount
+	rounding  money.RoundingMode
+
+	mu    sync.Mutex
+	lines []Line // in the order the items were first added
+}
+
+// Option configures a Cart made by New.
+type Option func(*Cart)
+
+// WithPricer prices items with p instead of shopping.PriceCheck.
+func WithPricer(p Pricer) Option {
+	return func(c *Cart) { c.price = p }
+}
+
+// WithTaxRate charges tax at basisPoints hundredths of a percent of the
+// discounted subtotal: 825 is 8.25%.
+func WithTaxRate(basisPoints int64) Option {
+	return func(c *Cart) { c.taxRate = basisPoints }
+}
+
+// WithDiscount applies d to the subtotal. Discounts add up, but never to
+// more than the subtotal.
+func WithDiscount(d Discount) Option {
+	return func(c *Cart) { c.discounts = append(c.discounts, d) }
+}
+
+// WithRounding rounds tax and percentage discounts with mode. The default is
+// money.HalfEven.
+func WithRounding(mode money.RoundingMode) Option {
+	return func(c *Cart) { c.rounding = mode }
+}
+
+// New returns an empty cart.
+func New(options ...Option) *Cart {
+	c := &Cart{price: shopping.PriceCheck}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+// Add puts quantity more of the item in the cart and reprices its line.
+func (c *Cart) Add(itemID int, quantity int64) error {
+	if quantity <= 0 {
+		return fmt.Errorf("%w: add %d of item %d", ErrInvalidQuantity, quantity, itemID)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i := c.find(itemID); i >= 0 {
+		return c.setLocked(itemID, c.lines[i].Quantity+quantity)
+	}
+	return c.setLocked(itemID, quantity)
+}
+
+// SetQuantity changes how many of the item are in the cart, adding it if it
+// isn't there yet, and reprices its line. A quantity of 0 removes it.
+func (c *Cart) SetQuantity(itemID int, quantity int64) error {
+	if quantity < 0 {
+		return fmt.Errorf("%w: set item %d to %d", ErrInvalidQuantity, itemID, quantity)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if quantity == 0 {
+		c.removeLocked(itemID)
+		return nil
+	}
+	return c.setLocked(itemID, quantity)
+}
+
+// Remove takes the item out of the cart. It reports whether it was there.
+func (c *Cart) Remove(itemID int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.removeLocked(itemID)
+}
+
+// Lines returns a copy of the lines in the cart.
+func (c *Cart) Lines() []Line {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.lines)
+}
+
+// Totals returns the totals of the cart at the prices its lines were last
+// priced at.
+func (c *Cart) Totals() (Totals, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totals(c.lines)
+}
+
+func (c *Cart) find(itemID int) int {
+	return slices.IndexFunc(c.lines, func(l Line) bool { return l.ItemID == itemID })
+}
+
+// setLocked prices quantity of the item and puts the line in the cart.
+// c.mu must be held.
+func (c *Cart) setLocked(itemID int, quantity int64) error {
+	line, err := c.priceLine(itemID, quantity)
+	if err != nil {
+		return err
+	}
+	i := c.find(itemID)
+	for j, l := range c.lines {
+		if j != i && l.UnitPrice.Currency() != line.UnitPrice.Currency() {
+			return fmt.Errorf("item %d: %w: cart is in %s, item in %s",
+				itemID, money.ErrCurrencyMismatch, l.UnitPrice.Currency(), line.UnitPrice.Currency())
+		}
+	}
+	if i >= 0 {
+		c.lines[i] = line
+	} else {
+		c.lines = append(c.lines, line)
+	}
+	return nil
+}
+
+func (c *Cart) removeLocked(itemID int) bool {
+	i := c.find(itemID)
+	if i < 0 {
+		return false
+	}
+	c.lines = slices.Delete(c.lines, i, i+1)
+	return true
+}
+
+// priceLine looks up the current price of the item.
+func (c *Cart) priceLine(itemID int, quantity int64) (Line, error) {
+	unit, ok := c.price(itemID)
+	if !ok {
+		return Line{}, fmt.Errorf("%w: %d", ErrItemNotFound, itemID)
+	}
+	total, err := unit.Mul(quantity)
+	if err != nil {
+		return Line{}, fmt.Errorf("item %d: %w", itemID, err)
+	}
+	return Line{ItemID: itemID, Quantity: quantity, UnitPrice: unit, Total: total}, nil
+}
+
+// Checkout reprices every line and turns the cart into an Order, emptying
+// the cart. It is all or nothing: if an item has disappeared, it returns a
+// *MissingItemsError naming all such items and leaves the cart as it was.
+// The pricer is called with the cart locked, so no Add or Remove can slip
+// in between pricing and the order.
+func (c *Cart) Checkout() (*Order, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.lines) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	lines := make([]Line, 0, len(c.lines))
+	var missing []int
+	for _, l := range c.lines {
+		line, err := c.priceLine(l.ItemID, l.Quantity)
+		if errors.Is(err, ErrItemNotFound) {
+			missing = append(missing, l.ItemID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if missing != nil {
+		return nil, &MissingItemsError{ItemIDs: missing}
+	}
+
+	totals, err := c.totals(lines)
+	if err != nil {
+		return nil, err
+	}
+	c.lines = nil
+	return newOrder(lines, totals), nil
+}
+
+// MissingItemsError is returned by Checkout when items in the cart no longer
+// exist. It matches ErrItemNotFound with errors.Is.
+type MissingItemsError struct {
+	ItemIDs []int
+}
+
+func (e *MissingItemsError) Error() string {
+	return fmt.Sprintf("cart: items %v no longer exist", e.ItemIDs)
+}
+
+func (e *MissingItemsError) Unwrap() error { return ErrItemNotFound }
@@ -0,0 +1,124 @@
+package cart
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"example.com/money"
+)
+
+// prices prices items from a map that tests can change under a lock.
+type prices struct {
+	mu sync.Mutex
+	m  map[int]money.Amount
+}
+
+func (p *prices) price(id int) (money.Amount, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	a, ok := p.m[id]
+	return a, ok
+}
+
+func (p *prices) set(id int, a money.Amount, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ok {
+		p.m[id] = a
+	} else {
+		delete(p.m, id)
+	}
+}
+
+func usd(s string) money.Amount { return money.MustParse(s, money.USD) }
+
+func newPrices() *prices {
+	return &prices{m: map[int]money.Amount{1: usd("56.99"), 2: usd("17.99"), 3: money.MustParse("1500", money.JPY)}}
+}
+
+func TestCheckout(t *testing.T) {
+	p := newPrices()
+	c := New(WithPricer(p.price), WithTaxRate(825), WithDiscount(PercentOff(10)), WithDiscount(AmountOff(usd("1"))))
+
+	if err := c.Add(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add(1, 1); err != nil { // 3 of item 1 now
+		t.Fatal(err)
+	}
+	if err := c.Add(3, 1); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Add of a JPY item error = %v, want ErrCurrencyMismatch", err)
+	}
+	if err := c.Add(99, 1); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("Add(99) error = %v, want ErrItemNotFound", err)
+	}
+	if err := c.SetQuantity(2, -1); !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("SetQuantity(2, -1) error = %v, want ErrInvalidQuantity", err)
+	}
+
+	// The price of item 2 goes up before checkout; the order has the new one.
+	p.set(2, usd("19.99"), true)
+	order, err := c.Checkout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3*56.99 + 19.99 = 190.96, less 19.10 (10%) and 1.00, plus 8.25% tax on 170.86.
+	want := Totals{Subtotal: usd("190.96"), Discount: usd("20.10"), Tax: usd("14.10"), Total: usd("184.96")}
+	if got := order.Totals(); got != want {
+		t.Errorf("Totals = %+v, want %+v", got, want)
+	}
+	if lines := order.Lines(); len(lines) != 2 || lines[0].Quantity != 3 || lines[1].UnitPrice != usd("19.99") {
+		t.Errorf("Lines = %+v", lines)
+	}
+	order.Lines()[0].Quantity = 100
+	if order.Lines()[0].Quantity != 3 {
+		t.Error("changing Lines() changed the order")
+	}
+	if _, err := c.Checkout(); !errors.Is(err, ErrEmptyCart) {
+		t.Errorf("second Checkout error = %v, want ErrEmptyCart", err)
+	}
+}
+
+func TestCheckoutMissingItem(t *testing.T) {
+	p := newPrices()
+	c := New(WithPricer(p.price))
+	c.Add(1, 1)
+	c.Add(2, 4)
+	p.set(2, money.Amount{}, false)
+
+	var missing *MissingItemsError
+	if _, err := c.Checkout(); !errors.As(err, &missing) || !errors.Is(err, ErrItemNotFound) || len(missing.ItemIDs) != 1 || missing.ItemIDs[0] != 2 {
+		t.Fatalf("Checkout error = %v, want item 2 missing", err)
+	}
+	if lines := c.Lines(); len(lines) != 2 {
+		t.Errorf("failed Checkout left %d lines, want the cart unchanged", len(lines))
+	}
+
+	c.Remove(2)
+	if order, err := c.Checkout(); err != nil || order.Totals().Total != usd("56.99") {
+		t.Errorf("Checkout = %+v, %v", order, err)
+	}
+}
+
+func TestConcurrentAdds(t *testing.T) {
+	c := New(WithPricer(newPrices().price))
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Add(2, 2)
+			c.SetQuantity(1, 1)
+		}()
+	}
+	wg.Wait()
+
+	totals, err := c.Totals()
+	if err != nil || totals.Subtotal != usd("1855.99") { // 100*17.99 + 56.99
+		t.Errorf("Totals = %+v, %v; want a subtotal of 1855.99", totals, err)
+	}
+}
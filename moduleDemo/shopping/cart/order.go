@@ -0,0 +1,105 @@
+package cart
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"example.com/money"
+)
+
+// Totals is what a cart or order costs.
+type Totals struct {
+	Subtotal money.Amount // sum of the line totals
+	Discount money.Amount // taken off the subtotal, at most all of it
+	Tax      money.Amount // on Subtotal - Discount
+	Total    money.Amount // Subtotal - Discount + Tax
+}
+
+// A Discount says how much to take off a cart's subtotal.
+type Discount interface {
+	Off(lines []Line, subtotal money.Amount, mode money.RoundingMode) (money.Amount, error)
+}
+
+// DiscountFunc adapts a function to a Discount.
+type DiscountFunc func(lines []Line, subtotal money.Amount, mode money.RoundingMode) (money.Amount, error)
+
+func (f DiscountFunc) Off(lines []Line, subtotal money.Amount, mode money.RoundingMode) (money.Amount, error) {
+	return f(lines, subtotal, mode)
+}
+
+// PercentOff takes percent percent off the subtotal.
+func PercentOff(percent int64) Discount {
+	return DiscountFunc(func(_ []Line, subtotal money.Amount, mode money.RoundingMode) (money.Amount, error) {
+		return subtotal.MulFrac(percent, 100, mode)
+	})
+}
+
+// AmountOff takes a fixed amount off the subtotal.
+func AmountOff(a money.Amount) Discount {
+	return DiscountFunc(func([]Line, money.Amount, money.RoundingMode) (money.Amount, error) {
+		return a, nil
+	})
+}
+
+// totals adds up lines and applies the cart's discounts and tax.
+func (c *Cart) totals(lines []Line) (Totals, error) {
+	var t Totals
+	var err error
+	for _, l := range lines {
+		if t.Subtotal, err = t.Subtotal.Add(l.Total); err != nil {
+			return Totals{}, err
+		}
+	}
+	t.Discount = money.Zero(t.Subtotal.Currency())
+	for _, d := range c.discounts {
+		off, err := d.Off(slices.Clone(lines), t.Subtotal, c.rounding)
+		if err != nil {
+			return Totals{}, fmt.Errorf("discount: %w", err)
+		}
+		if off.IsNegative() {
+			return Totals{}, fmt.Errorf("discount: %s is negative", off)
+		}
+		if t.Discount, err = t.Discount.Add(off); err != nil {
+			return Totals{}, fmt.Errorf("discount: %w", err)
+		}
+	}
+	if cmp, err := t.Discount.Cmp(t.Subtotal); err != nil {
+		return Totals{}, err
+	} else if cmp > 0 {
+		t.Discount = t.Subtotal
+	}
+
+	taxable, err := t.Subtotal.Sub(t.Discount)
+	if err != nil {
+		return Totals{}, err
+	}
+	if t.Tax, err = taxable.MulFrac(c.taxRate, 10000, c.rounding); err != nil {
+		return Totals{}, err
+	}
+	if t.Total, err = taxable.Add(t.Tax); err != nil {
+		return Totals{}, err
+	}
+	return t, nil
+}
+
+// Order is a cart as it was checked out. It can't be changed: Lines returns
+// a copy.
+type Order struct {
+	lines    []Line
+	totals   Totals
+	placedAt time.Time
+}
+
+func newOrder(lines []Line, totals Totals) *Order {
+	return &Order{lines: lines, totals: totals, placedAt: time.Now()}
+}
+
+// Lines returns the lines of the order at their checkout prices.
+func (o *Order) Lines() []Line { return slices.Clone(o.lines) }
+
+// Totals returns what the order cost.
+func (o *Order) Totals() Totals { return o.totals }
+
+// PlacedAt returns when the order was checked out.
+func (o *Order) PlacedAt() time.Time { return o.placedAt }
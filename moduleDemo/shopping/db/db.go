@@ -1,11 +1,10 @@
+// Package db stores the items of the catalogue: SQLiteItems in a SQLite
+// database, MemoryItems in a map. Both are a models.Store.
 package db // 包名和文件夹名一致
 
-type Item struct {
-	Price float64
-}
+import "moduleDemo/shopping/models"
 
-func LoadItem(id int) *Item {
-	return &Item{
-		Price: 9.001,
-	}
-}
+var (
+	_ models.Store = (*SQLiteItems)(nil)
+	_ models.Store = (*MemoryItems)(nil)
+)
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"moduleDemo/shopping/models"
+
+	"example.com/money"
+)
+
+// testStore checks the models.Store contract, which every store in this
+// package must keep. s must hold seedItems and nothing else.
+func testStore(t *testing.T, s models.Store) {
+	t.Helper()
+	giant := models.Item{ID: 4343, SKU: "VINYL-4343", Name: "Giant Steps (vinyl)", Price: money.MustParse("63.99", money.USD), Category: "vinyl"}
+	before := time.Now().Add(-time.Second)
+	if err := s.Put(giant); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get(4343)
+	if err != nil || got.SKU != giant.SKU || got.Name != giant.Name || got.Price != giant.Price || got.Category != giant.Category {
+		t.Fatalf("Get(4343) = %+v, %v; want %+v", got, err, giant)
+	}
+	if got.CreatedAt.Before(before) || !got.UpdatedAt.Equal(got.CreatedAt) {
+		t.Errorf("new item timestamps = %v, %v; want both now", got.CreatedAt, got.UpdatedAt)
+	}
+
+	time.Sleep(time.Millisecond)
+	giant.Price = money.MustParse("59.99", money.USD)
+	if err := s.Put(giant); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := s.Get(4343)
+	if updated.Price != giant.Price || !updated.CreatedAt.Equal(got.CreatedAt) || !updated.UpdatedAt.After(got.UpdatedAt) {
+		t.Errorf("after a second Put = %+v; want the new price, the same CreatedAt and a later UpdatedAt", updated)
+	}
+
+	if item, err := s.Get(99); item != nil || !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Get(99) = %+v, %v, want nil, ErrNotFound", item, err)
+	}
+	items, err := s.GetMany([]int{1, 4343, 99})
+	if err != nil || len(items) != 2 || items[1].Price != seedItems[0].Price || items[4343].Price != giant.Price {
+		t.Errorf("GetMany(1, 4343, 99) = %v, %v, want items 1 and 4343", items, err)
+	}
+	if items, err := s.GetMany(nil); err != nil || len(items) != 0 {
+		t.Errorf("GetMany(nil) = %v, %v", items, err)
+	}
+}
+
+func TestMemoryItems(t *testing.T) {
+	testStore(t, NewMemoryItems(seedItems...))
+}
+
+func TestSQLiteItems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalogue.db")
+	items, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, items)
+	items.Close()
+
+	// Opening it again keeps the items and doesn't seed twice.
+	items, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer items.Close()
+	if all, err := items.GetMany([]int{1, 2, 3, 4343}); err != nil || len(all) != 4 || all[1].SKU != seedItems[0].SKU {
+		t.Errorf("after reopening GetMany = %v, %v; want the seed items and 4343", all, err)
+	}
+}
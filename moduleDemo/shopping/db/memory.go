@@ -0,0 +1,57 @@
+package db
+
+import (
+	"sync"
+	"time"
+
+	"moduleDemo/shopping/models"
+)
+
+// MemoryItems is a models.Store kept in a map, a fake for tests.
+type MemoryItems struct {
+	mu    sync.RWMutex
+	items map[int]models.Item
+}
+
+// NewMemoryItems returns a MemoryItems holding items.
+func NewMemoryItems(items ...models.Item) *MemoryItems {
+	m := &MemoryItems{items: map[int]models.Item{}}
+	for _, item := range items {
+		m.Put(item)
+	}
+	return m
+}
+
+func (m *MemoryItems) Get(id int) (*models.Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	item, ok := m.items[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &item, nil // a copy, so callers can't change the catalogue
+}
+
+func (m *MemoryItems) GetMany(ids []int) (map[int]*models.Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := make(map[int]*models.Item, len(ids))
+	for _, id := range ids {
+		if item, ok := m.items[id]; ok {
+			items[id] = &item
+		}
+	}
+	return items, nil
+}
+
+func (m *MemoryItems) Put(item models.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item.UpdatedAt = time.Now().UTC()
+	item.CreatedAt = item.UpdatedAt
+	if old, ok := m.items[item.ID]; ok {
+		item.CreatedAt = old.CreatedAt
+	}
+	m.items[item.ID] = item
+	return nil
+}
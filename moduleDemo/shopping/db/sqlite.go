@@ -0,0 +1,142 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"moduleDemo/shopping/models"
+
+	"example.com/money"
+
+	_ "github.com/mattn/go-sqlite3" // registers the "sqlite3" driver
+)
+
+// SQLiteItems is a models.Store backed by the items table of a SQLite
+// database. Prices are stored exactly, as a whole number of minor units
+// (cents) and a currency code.
+type SQLiteItems struct {
+	db *sql.DB
+}
+
+// seedItems fill a new catalogue, so the demo has something to look up.
+var seedItems = []models.Item{
+	{ID: 1, SKU: "VINYL-0001", Name: "Blue Train (vinyl)", Price: money.MustParse("56.99", money.USD), Category: "vinyl"},
+	{ID: 2, SKU: "CD-0002", Name: "Jeru (CD)", Price: money.MustParse("17.99", money.USD), Category: "cd"},
+	{ID: 3, SKU: "CD-0003", Name: "Sarah Vaughan and Clifford Brown (CD)", Price: money.MustParse("39.99", money.USD), Category: "cd"},
+}
+
+// OpenSQLite opens the catalogue in the SQLite file at path (":memory:" for
+// one that lives as long as the returned repository), creating the items
+// table with seedItems if it doesn't exist yet.
+func OpenSQLite(path string) (*SQLiteItems, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// Every connection to ":memory:" is a database of its own, so keep one.
+	db.SetMaxOpenConns(1)
+
+	s := &SQLiteItems{db: db}
+	if err := s.init(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("OpenSQLite %q: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *SQLiteItems) init() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS items (
+  id          INTEGER PRIMARY KEY,
+  sku         TEXT NOT NULL,
+  name        TEXT NOT NULL,
+  price_minor INTEGER NOT NULL,
+  currency    TEXT NOT NULL,
+  category    TEXT NOT NULL,
+  created_at  TIMESTAMP NOT NULL,
+  updated_at  TIMESTAMP NOT NULL
+)`); err != nil {
+		return err
+	}
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM items").Scan(&n); err != nil || n > 0 {
+		return err
+	}
+	for _, item := range seedItems {
+		if err := s.Put(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemColumns are the columns scanItem reads.
+const itemColumns = "id, sku, name, price_minor, currency, category, created_at, updated_at"
+
+func (s *SQLiteItems) Get(id int) (*models.Item, error) {
+	item, err := scanItem(s.db.QueryRow("SELECT "+itemColumns+" FROM items WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *SQLiteItems) GetMany(ids []int) (map[int]*models.Item, error) {
+	items := make(map[int]*models.Item, len(ids))
+	if len(ids) == 0 {
+		return items, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.Repeat(", ?", len(ids))[2:]
+	rows, err := s.db.Query("SELECT "+itemColumns+" FROM items WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items[item.ID] = item
+	}
+	return items, rows.Err()
+}
+
+// scanItem reads the itemColumns of a row.
+func scanItem(row interface{ Scan(dest ...any) error }) (*models.Item, error) {
+	var item models.Item
+	var minor int64
+	var code string
+	if err := row.Scan(&item.ID, &item.SKU, &item.Name, &minor, &code, &item.Category, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		return nil, err
+	}
+	currency, err := money.ParseCurrency(code)
+	if err != nil {
+		return nil, fmt.Errorf("item %d: %w", item.ID, err)
+	}
+	item.Price = money.New(minor, currency)
+	return &item, nil
+}
+
+func (s *SQLiteItems) Put(item models.Item) error {
+	now := time.Now().UTC()
+	_, err := s.db.Exec(`INSERT INTO items (`+itemColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET sku = excluded.sku, name = excluded.name, price_minor = excluded.price_minor,
+  currency = excluded.currency, category = excluded.category, updated_at = excluded.updated_at`,
+		item.ID, item.SKU, item.Name, item.Price.Minor(), string(item.Price.Currency()), item.Category, now, now)
+	return err
+}
+
+// Close closes the database.
+func (s *SQLiteItems) Close() error {
+	return s.db.Close()
+}
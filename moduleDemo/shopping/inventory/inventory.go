@@ -0,0 +1,251 @@
+// Package inventory keeps stock levels of shopping items and reservations
+// of stock for checkouts in progress.
+//
+// A checkout reserves what it is buying, which takes it out of the stock
+// available to others, then commits the reservation when the order is paid
+// or releases it when the order is abandoned. A reservation that is neither
+// committed nor released before its TTL runs out expires, and its stock
+// becomes available again; RunReaper expires them in the background.
+//
+//	inv := inventory.New()
+//	inv.SetStock(1, 10)
+//	go inv.RunReaper(ctx, time.Minute)
+//	r, err := inv.Reserve(map[int]int64{1: 2}, 15*time.Minute)
+//	...
+//	err = inv.Commit(r.ID)
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownItem means the item has no stock level.
+	ErrUnknownItem = errors.New("inventory: unknown item")
+	// ErrOutOfStock means fewer units are available than were asked for.
+	ErrOutOfStock = errors.New("inventory: not enough stock")
+	// ErrNoReservation means the reservation doesn't exist: it was never
+	// made, or it has been committed, released or has expired.
+	ErrNoReservation = errors.New("inventory: no such reservation")
+)
+
+// Stock is the stock level of an item.
+type Stock struct {
+	OnHand   int64 // units in the warehouse
+	Reserved int64 // units held for checkouts in progress
+}
+
+// Available returns the units that can still be reserved.
+func (s Stock) Available() int64 { return s.OnHand - s.Reserved }
+
+// ReservationID identifies a reservation.
+type ReservationID uint64
+
+// Reservation is stock held for a checkout until ExpiresAt.
+type Reservation struct {
+	ID        ReservationID
+	Items     map[int]int64 // units of each item ID
+	ExpiresAt time.Time
+}
+
+// Inventory holds the stock of every item. It is safe for concurrent use:
+// every operation holds one lock for its whole length, so two checkouts can
+// never both reserve the last unit.
+type Inventory struct {
+	now func() time.Time
+
+	mu           sync.Mutex
+	stock        map[int]*Stock
+	reservations map[ReservationID]*Reservation
+	lastID       ReservationID
+}
+
+// Option configures an Inventory made by New.
+type Option func(*Inventory)
+
+// WithClock makes the Inventory tell the time with now instead of time.Now,
+// so that tests can expire reservations without waiting.
+func WithClock(now func() time.Time) Option {
+	return func(inv *Inventory) { inv.now = now }
+}
+
+// New returns an Inventory with no items.
+func New(options ...Option) *Inventory {
+	inv := &Inventory{
+		now:          time.Now,
+		stock:        map[int]*Stock{},
+		reservations: map[ReservationID]*Reservation{},
+	}
+	for _, o := range options {
+		o(inv)
+	}
+	return inv
+}
+
+// SetStock sets the units of the item on hand, after a delivery or a stock
+// take. It fails with ErrOutOfStock if that is fewer than are reserved.
+func (inv *Inventory) SetStock(itemID int, onHand int64) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	s, ok := inv.stock[itemID]
+	if !ok {
+		s = &Stock{}
+		inv.stock[itemID] = s
+	}
+	if onHand < s.Reserved {
+		// Expired reservations the reaper hasn't got to yet may be in the way.
+		inv.expireLocked(inv.now())
+	}
+	if onHand < s.Reserved {
+		return fmt.Errorf("%w: item %d has %d units reserved, can't set it to %d", ErrOutOfStock, itemID, s.Reserved, onHand)
+	}
+	s.OnHand = onHand
+	return nil
+}
+
+// Stock returns the stock level of the item. Reservations that have expired
+// count as reserved until the reaper or a Reserve expires them.
+func (inv *Inventory) Stock(itemID int) (Stock, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	s, ok := inv.stock[itemID]
+	if !ok {
+		return Stock{}, fmt.Errorf("%w: %d", ErrUnknownItem, itemID)
+	}
+	return *s, nil
+}
+
+// InStock reports whether at least one unit of the item is available.
+func (inv *Inventory) InStock(itemID int) bool {
+	s, err := inv.Stock(itemID)
+	return err == nil && s.Available() > 0
+}
+
+// Reserve holds the units of each item in items for ttl. It is all or
+// nothing: if any item is unknown or short of stock, nothing is reserved.
+func (inv *Inventory) Reserve(items map[int]int64, ttl time.Duration) (Reservation, error) {
+	if ttl <= 0 {
+		return Reservation{}, fmt.Errorf("inventory: reservation TTL %v must be positive", ttl)
+	}
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	now := inv.now()
+	err := inv.check(items)
+	if errors.Is(err, ErrOutOfStock) {
+		// Expired reservations the reaper hasn't got to yet may hold the stock.
+		inv.expireLocked(now)
+		err = inv.check(items)
+	}
+	if err != nil {
+		return Reservation{}, err
+	}
+	for id, n := range items {
+		inv.stock[id].Reserved += n
+	}
+	inv.lastID++
+	r := &Reservation{ID: inv.lastID, Items: maps.Clone(items), ExpiresAt: now.Add(ttl)}
+	inv.reservations[r.ID] = r
+	return r.copy(), nil
+}
+
+// check returns an error if items can't all be reserved.
+func (inv *Inventory) check(items map[int]int64) error {
+	for id, n := range items {
+		s, ok := inv.stock[id]
+		switch {
+		case n <= 0:
+			return fmt.Errorf("inventory: can't reserve %d units of item %d", n, id)
+		case !ok:
+			return fmt.Errorf("%w: %d", ErrUnknownItem, id)
+		case s.Available() < n:
+			return fmt.Errorf("%w: item %d has %d units available, %d wanted", ErrOutOfStock, id, s.Available(), n)
+		}
+	}
+	return nil
+}
+
+// Commit turns the reservation into a sale: its units leave the stock for
+// good.
+func (inv *Inventory) Commit(id ReservationID) error {
+	return inv.finish(id, func(s *Stock, n int64) {
+		s.OnHand -= n
+		s.Reserved -= n
+	})
+}
+
+// Release cancels the reservation, making its units available again.
+func (inv *Inventory) Release(id ReservationID) error {
+	return inv.finish(id, func(s *Stock, n int64) { s.Reserved -= n })
+}
+
+// finish removes a live reservation and applies settle to each of its items.
+func (inv *Inventory) finish(id ReservationID, settle func(s *Stock, n int64)) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	r, ok := inv.reservations[id]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrNoReservation, id)
+	}
+	if !inv.now().Before(r.ExpiresAt) {
+		// Expired, though not reaped yet: too late to commit it.
+		inv.expireLocked(inv.now())
+		return fmt.Errorf("%w: %d expired at %v", ErrNoReservation, id, r.ExpiresAt)
+	}
+	for itemID, n := range r.Items {
+		settle(inv.stock[itemID], n)
+	}
+	delete(inv.reservations, id)
+	return nil
+}
+
+// Expire releases every reservation whose TTL has run out and returns how
+// many there were. RunReaper calls it. An expired reservation can't be
+// committed even before it is reaped, and Reserve reaps them itself rather
+// than fail for want of stock they hold.
+func (inv *Inventory) Expire() int {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.expireLocked(inv.now())
+}
+
+func (inv *Inventory) expireLocked(now time.Time) int {
+	n := 0
+	for id, r := range inv.reservations {
+		if now.Before(r.ExpiresAt) {
+			continue
+		}
+		for itemID, units := range r.Items {
+			inv.stock[itemID].Reserved -= units
+		}
+		delete(inv.reservations, id)
+		n++
+	}
+	return n
+}
+
+// RunReaper expires reservations every interval until ctx is done. Run it
+// in its own goroutine.
+func (inv *Inventory) RunReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			inv.Expire()
+		}
+	}
+}
+
+func (r *Reservation) copy() Reservation {
+	c := *r
+	c.Items = maps.Clone(r.Items)
+	return c
+}
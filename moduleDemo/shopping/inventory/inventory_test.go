@@ -0,0 +1,128 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// clock is a time that tests move by hand.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestReservations(t *testing.T) {
+	clk := &clock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	inv := New(WithClock(clk.Now))
+	inv.SetStock(1, 5)
+	inv.SetStock(2, 1)
+
+	committed, err := inv.Reserve(map[int]int64{1: 2, 2: 1}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// All or nothing: item 2 is short, so item 1 isn't reserved either.
+	if _, err := inv.Reserve(map[int]int64{1: 1, 2: 1}, time.Minute); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("Reserve of the last unit twice error = %v, want ErrOutOfStock", err)
+	}
+	if _, err := inv.Reserve(map[int]int64{9: 1}, time.Minute); !errors.Is(err, ErrUnknownItem) {
+		t.Errorf("Reserve(9) error = %v, want ErrUnknownItem", err)
+	}
+	released, _ := inv.Reserve(map[int]int64{1: 1}, time.Minute)
+	expired, _ := inv.Reserve(map[int]int64{1: 1}, time.Second)
+	if s, _ := inv.Stock(1); s != (Stock{OnHand: 5, Reserved: 4}) {
+		t.Errorf("Stock(1) = %+v, want 4 of 5 reserved", s)
+	}
+	if err := inv.SetStock(1, 3); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("SetStock below the reserved units error = %v, want ErrOutOfStock", err)
+	}
+
+	if err := inv.Commit(committed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Release(released.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Commit(committed.ID); !errors.Is(err, ErrNoReservation) {
+		t.Errorf("second Commit error = %v, want ErrNoReservation", err)
+	}
+	clk.Advance(2 * time.Second)
+	if err := inv.Commit(expired.ID); !errors.Is(err, ErrNoReservation) {
+		t.Errorf("Commit after the TTL error = %v, want ErrNoReservation", err)
+	}
+
+	if !inv.InStock(1) || inv.InStock(2) || inv.InStock(9) {
+		t.Errorf("InStock(1, 2, 9) = %v, %v, %v; want true, false, false", inv.InStock(1), inv.InStock(2), inv.InStock(9))
+	}
+	for id, want := range map[int]Stock{1: {OnHand: 3}, 2: {OnHand: 0}} {
+		if s, _ := inv.Stock(id); s != want {
+			t.Errorf("Stock(%d) = %+v, want %+v", id, s, want)
+		}
+	}
+}
+
+func TestNoOverselling(t *testing.T) {
+	inv := New()
+	inv.SetStock(1, 10)
+
+	var wg sync.WaitGroup
+	var reserved atomic.Int64
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := inv.Reserve(map[int]int64{1: 1}, time.Minute)
+			if err != nil {
+				return
+			}
+			reserved.Add(1)
+			if r.ID%2 == 0 {
+				inv.Commit(r.ID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s, _ := inv.Stock(1); reserved.Load() != 10 || s != (Stock{OnHand: 5, Reserved: 5}) {
+		t.Errorf("%d reservations succeeded leaving %+v, want 10 leaving 5 on hand, 5 reserved", reserved.Load(), s)
+	}
+}
+
+func TestReaper(t *testing.T) {
+	inv := New()
+	inv.SetStock(1, 1)
+	if _, err := inv.Reserve(map[int]int64{1: 1}, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go inv.RunReaper(ctx, 5*time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if s, _ := inv.Stock(1); s.Reserved == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the reaper didn't expire the reservation")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
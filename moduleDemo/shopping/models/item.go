@@ -1,5 +1,38 @@
 package models // 把共用的item抽取到models
 
+import (
+	"errors"
+	"time"
+
+	"example.com/money"
+)
+
+// ErrNotFound is returned when no item has the requested id.
+var ErrNotFound = errors.New("item not found")
+
+// Item is a product in the catalogue.
 type Item struct {
-	Price float64
+	ID        int
+	SKU       string // stock keeping unit, e.g. "VINYL-0001"
+	Name      string
+	Price     money.Amount // unit price, in the item's currency
+	Category  string       // e.g. "vinyl", "cd"
+	CreatedAt time.Time    // set by Store.Put when the item is first stored
+	UpdatedAt time.Time    // set by Store.Put every time
+}
+
+// Currency returns the currency the item is priced in.
+func (i Item) Currency() money.Currency { return i.Price.Currency() }
+
+// Store keeps the items of a catalogue. package db has a SQLite Store and an
+// in-memory one.
+type Store interface {
+	// Get returns the item with id, or an error wrapping ErrNotFound.
+	Get(id int) (*Item, error)
+	// GetMany returns the items with ids, by id, in one query rather than
+	// one per item. IDs with no item are left out.
+	GetMany(ids []int) (map[int]*Item, error)
+	// Put adds item, or replaces the item with the same ID, setting its
+	// timestamps.
+	Put(item Item) error
 }
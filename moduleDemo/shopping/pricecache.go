@@ -0,0 +1,225 @@
+package shopping
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"moduleDemo/shopping/models"
+
+	"example.com/money"
+)
+
+// Prices is the cache PriceCheckMany reads through.
+var Prices = NewPriceCache(time.Minute, 100)
+
+// PriceCheckMany returns the prices of the items with ids, by id, through
+// Prices. IDs with no item are left out of the map.
+func PriceCheckMany(ctx context.Context, ids []int) (map[int]money.Amount, error) {
+	return Prices.PriceCheckMany(ctx, ids)
+}
+
+// PriceCache is a read-through cache of item prices in front of
+// Catalogue.GetMany. Items that don't exist are cached too, so looking for them
+// again doesn't reach the database.
+//
+// Concurrent lookups of an item that isn't cached share one load, like
+// golang.org/x/sync/singleflight. A PriceCache is safe for concurrent use.
+type PriceCache struct {
+	ttl       time.Duration
+	batchSize int
+	load      func(ids []int) (map[int]*models.Item, error)
+	now       func() time.Time
+
+	mu       sync.Mutex
+	entries  map[int]priceEntry
+	expiry   []expiringPrice    // entries by expiry, for sweeping
+	inflight map[int]*priceLoad // the load of each item being loaded
+}
+
+type priceEntry struct {
+	price   money.Amount
+	found   bool
+	expires time.Time
+}
+
+// expiringPrice is an entry cached until expires. The entry may since have
+// been invalidated or replaced, in which case it has another expiry.
+type expiringPrice struct {
+	id      int
+	expires time.Time
+}
+
+// priceLoad is one batch being loaded. items and err are set before done
+// is closed.
+type priceLoad struct {
+	done  chan struct{}
+	items map[int]*models.Item
+	err   error
+}
+
+// NewPriceCache returns a cache that keeps prices for ttl and loads at most
+// batchSize items per query. A batchSize below 1 loads one item at a time.
+func NewPriceCache(ttl time.Duration, batchSize int) *PriceCache {
+	return &PriceCache{
+		ttl:       ttl,
+		batchSize: max(batchSize, 1),
+		load:      func(ids []int) (map[int]*models.Item, error) { return Catalogue.GetMany(ids) },
+		now:       time.Now,
+		entries:   map[int]priceEntry{},
+		inflight:  map[int]*priceLoad{},
+	}
+}
+
+// PriceCheck is the cached PriceCheck.
+func (c *PriceCache) PriceCheck(ctx context.Context, itemID int) (money.Amount, bool, error) {
+	prices, err := c.PriceCheckMany(ctx, []int{itemID})
+	price, ok := prices[itemID]
+	return price, ok, err
+}
+
+// PriceCheckMany returns the prices of the items with ids, by id, leaving
+// out the ids with no item. Each id is looked up once however often it
+// appears. Ids that aren't cached are loaded batchSize at a time, or waited
+// for if another call is already loading them.
+//
+// If a load fails, every call waiting for it gets the error, even one whose
+// ctx is fine; it can simply try again.
+func (c *PriceCache) PriceCheckMany(ctx context.Context, ids []int) (map[int]money.Amount, error) {
+	prices := make(map[int]money.Amount, len(ids))
+	var missing []int                 // ids nobody is loading yet
+	waiting := map[*priceLoad][]int{} // the ids wanted from each load
+	now := c.now()
+
+	c.mu.Lock()
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if e, ok := c.entries[id]; ok && now.Before(e.expires) {
+			if e.found {
+				prices[id] = e.price
+			}
+		} else if l, ok := c.inflight[id]; ok {
+			waiting[l] = append(waiting[l], id)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	// Claim the missing ids before unlocking, so that other calls wait for
+	// these loads rather than starting their own.
+	mine := map[*priceLoad][]int{}
+	for batch := range slices.Chunk(missing, c.batchSize) {
+		l := &priceLoad{done: make(chan struct{})}
+		for _, id := range batch {
+			c.inflight[id] = l
+		}
+		mine[l] = batch
+		waiting[l] = batch
+	}
+	c.mu.Unlock()
+
+	for l, batch := range mine {
+		c.run(ctx, l, batch)
+	}
+
+	for l, ids := range waiting {
+		select {
+		case <-l.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if l.err != nil {
+			return nil, l.err
+		}
+		for _, id := range ids {
+			if item, ok := l.items[id]; ok {
+				prices[id] = item.Price
+			}
+		}
+	}
+	return prices, nil
+}
+
+// run loads ids, caches the prices and wakes whoever waits for l, even if
+// the load panics.
+func (c *PriceCache) run(ctx context.Context, l *priceLoad, ids []int) {
+	defer close(l.done)
+	if l.err = ctx.Err(); l.err == nil {
+		l.items, l.err = c.safeLoad(ids)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.expire(now)
+	expires := now.Add(c.ttl)
+	for _, id := range ids {
+		if c.inflight[id] != l {
+			continue // invalidated while loading: the price may be stale
+		}
+		delete(c.inflight, id)
+		if l.err == nil {
+			item, found := l.items[id]
+			e := priceEntry{found: found, expires: expires}
+			if found {
+				e.price = item.Price
+			}
+			c.entries[id] = e
+			c.expiry = append(c.expiry, expiringPrice{id, expires})
+		}
+	}
+}
+
+// safeLoad calls c.load, turning a panic into an error so the ids it claimed
+// aren't left in flight for good.
+func (c *PriceCache) safeLoad(ids []int) (items map[int]*models.Item, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("loading prices: panic: %v", r)
+		}
+	}()
+	return c.load(ids)
+}
+
+// expire drops the entries that expired by now. Every entry has the same
+// ttl, so c.expiry is in order and only the expired ones are looked at.
+// c.mu must be held.
+func (c *PriceCache) expire(now time.Time) {
+	n := 0
+	for _, e := range c.expiry {
+		if now.Before(e.expires) {
+			break
+		}
+		if c.entries[e.id].expires.Equal(e.expires) {
+			delete(c.entries, e.id)
+		}
+		n++
+	}
+	c.expiry = slices.Delete(c.expiry, 0, n)
+}
+
+// Invalidate drops the cached prices of ids, so the next lookup loads them
+// again. Call it after changing a price. A load already under way for an
+// id isn't cached when it finishes, since it may have read the old price.
+func (c *PriceCache) Invalidate(ids ...int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		delete(c.entries, id)
+		delete(c.inflight, id)
+	}
+}
+
+// InvalidateAll drops every cached price.
+func (c *PriceCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.expiry = nil
+	clear(c.inflight)
+}
@@ -0,0 +1,142 @@
+package shopping
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"moduleDemo/shopping/db"
+	"moduleDemo/shopping/models"
+
+	"example.com/money"
+)
+
+func TestPriceCache(t *testing.T) {
+	catalogue := db.NewMemoryItems(
+		models.Item{ID: 1, Price: money.MustParse("56.99", money.USD)},
+		models.Item{ID: 2, Price: money.MustParse("17.99", money.USD)},
+		models.Item{ID: 3, Price: money.MustParse("39.99", money.USD)},
+	)
+	var batches [][]int
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewPriceCache(time.Minute, 2)
+	c.now = func() time.Time { return now }
+	c.load = func(ids []int) (map[int]*models.Item, error) {
+		batches = append(batches, ids)
+		return catalogue.GetMany(ids)
+	}
+	ctx := context.Background()
+
+	prices, err := c.PriceCheckMany(ctx, []int{1, 2, 2, 3, 99, 1})
+	if err != nil || len(prices) != 3 || prices[2] != money.MustParse("17.99", money.USD) {
+		t.Errorf("PriceCheckMany = %v, %v; want items 1, 2 and 3", prices, err)
+	}
+	if len(batches) != 2 || len(batches[0])+len(batches[1]) != 4 {
+		t.Errorf("loaded %v, want 4 distinct ids in 2 batches", batches)
+	}
+
+	// Cached, including that 99 doesn't exist.
+	batches = nil
+	if _, ok, _ := c.PriceCheck(ctx, 99); ok || len(batches) != 0 {
+		t.Errorf("PriceCheck(99) = %v after loading %v, want false from the cache", ok, batches)
+	}
+
+	catalogue.Put(models.Item{ID: 1, Price: money.MustParse("49.99", money.USD)})
+	if price, _, _ := c.PriceCheck(ctx, 1); price != money.MustParse("56.99", money.USD) {
+		t.Errorf("PriceCheck(1) before invalidating = %v, want the cached 56.99", price)
+	}
+	c.Invalidate(1)
+	if price, _, _ := c.PriceCheck(ctx, 1); price != money.MustParse("49.99", money.USD) {
+		t.Errorf("PriceCheck(1) after invalidating = %v, want 49.99", price)
+	}
+
+	catalogue.Put(models.Item{ID: 2, Price: money.MustParse("19.99", money.USD)})
+	now = now.Add(2 * time.Minute)
+	if price, _, _ := c.PriceCheck(ctx, 2); price != money.MustParse("19.99", money.USD) {
+		t.Errorf("PriceCheck(2) after the TTL = %v, want 19.99", price)
+	}
+	// Loading 2 again swept the other expired prices.
+	if len(c.entries) != 1 || len(c.expiry) != 1 {
+		t.Errorf("after the TTL %d prices are cached and %d queued to expire, want 1", len(c.entries), len(c.expiry))
+	}
+
+	// A batch size below 1 loads one at a time rather than panicking.
+	c = NewPriceCache(time.Minute, 0)
+	c.load = catalogue.GetMany
+	if prices, err := c.PriceCheckMany(ctx, []int{1, 2}); err != nil || len(prices) != 2 {
+		t.Errorf("PriceCheckMany with a batch size of 0 = %v, %v; want items 1 and 2", prices, err)
+	}
+}
+
+func TestPriceCacheCoalesces(t *testing.T) {
+	var loads atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	c := NewPriceCache(time.Minute, 100)
+	c.load = func(ids []int) (map[int]*models.Item, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return map[int]*models.Item{1: {ID: 1, Price: money.MustParse("1", money.USD)}}, nil
+	}
+
+	var wg sync.WaitGroup
+	check := func() {
+		defer wg.Done()
+		if _, ok, err := c.PriceCheck(context.Background(), 1); !ok || err != nil {
+			t.Errorf("PriceCheck(1) = %v, %v", ok, err)
+		}
+	}
+	wg.Add(1)
+	go check()
+	<-started // the first call has claimed item 1
+	for range 10 {
+		wg.Add(1)
+		go check()
+	}
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("%d loads, want 1 shared by all calls", n)
+	}
+}
+
+// TestPriceCachePanickingLoad checks that a load that panics fails the calls
+// waiting for it rather than leaving them blocked, and can be retried.
+func TestPriceCachePanickingLoad(t *testing.T) {
+	var loads atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	c := NewPriceCache(time.Minute, 100)
+	c.load = func(ids []int) (map[int]*models.Item, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+			<-release
+			panic("catalogue is down")
+		}
+		return map[int]*models.Item{1: {ID: 1, Price: money.MustParse("1", money.USD)}}, nil
+	}
+
+	first := make(chan error)
+	go func() {
+		_, _, err := c.PriceCheck(context.Background(), 1)
+		first <- err
+	}()
+	<-started // the first call has claimed item 1
+	waiter := make(chan error)
+	go func() {
+		_, _, err := c.PriceCheck(context.Background(), 1)
+		waiter <- err
+	}()
+	close(release)
+	if err := <-first; err == nil {
+		t.Error("PriceCheck(1) with a panicking load: want error")
+	}
+	<-waiter // either failed with the first call or loaded again; it mustn't block
+
+	if _, ok, err := c.PriceCheck(context.Background(), 1); !ok || err != nil {
+		t.Errorf("PriceCheck(1) after the panic = %v, %v; want a fresh load", ok, err)
+	}
+}
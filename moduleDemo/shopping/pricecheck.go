@@ -2,12 +2,44 @@ package shopping
 
 import (
 	"moduleDemo/shopping/db"
+	"moduleDemo/shopping/models"
+
+	"example.com/money"
 )
 
-func PriceCheck(itemId int) (float64, bool) {
-	item := db.LoadItem(itemId)
-	if item == nil {
-		return 0, false
+// Catalogue is the store PriceCheck and Prices read items from. main points
+// it at a SQLite catalogue (see db.OpenSQLite), tests at a db.MemoryItems.
+var Catalogue models.Store = db.NewMemoryItems()
+
+// PriceCheck returns the price of the item with itemId, and false if there
+// is no such item or it can't be read.
+func PriceCheck(itemId int) (money.Amount, bool) {
+	item, err := Catalogue.Get(itemId)
+	if err != nil {
+		return money.Amount{}, false
 	}
 	return item.Price, true
 }
+
+// PriceCheck2 is PriceCheck.
+//
+// Deprecated: it was a second path through the models.Item copy of the
+// item type; use PriceCheck.
+func PriceCheck2(itemId int) (money.Amount, bool) {
+	return PriceCheck(itemId)
+}
+
+// SetPrice changes the price of the item with itemID in Catalogue and drops
+// the old one from Prices.
+func SetPrice(itemID int, price money.Amount) error {
+	item, err := Catalogue.Get(itemID)
+	if err != nil {
+		return err
+	}
+	item.Price = price
+	if err := Catalogue.Put(*item); err != nil {
+		return err
+	}
+	Prices.Invalidate(itemID)
+	return nil
+}
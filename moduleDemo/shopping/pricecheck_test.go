@@ -0,0 +1,34 @@
+package shopping
+
+import (
+	"context"
+	"testing"
+
+	"moduleDemo/shopping/db"
+	"moduleDemo/shopping/models"
+
+	"example.com/money"
+)
+
+func TestPriceCheck(t *testing.T) {
+	Catalogue = db.NewMemoryItems(models.Item{ID: 7, Name: "Jeru", Price: money.MustParse("17.99", money.EUR)})
+	Prices.InvalidateAll()
+
+	if price, ok := PriceCheck(7); price.String() != "17.99 EUR" || !ok {
+		t.Errorf("PriceCheck(7) = %v, %v, want 17.99 EUR, true", price, ok)
+	}
+	if price, ok := PriceCheck(4343); !price.IsZero() || ok {
+		t.Errorf("PriceCheck(4343) = %v, %v, want 0, false", price, ok)
+	}
+
+	// SetPrice reaches the cache too.
+	if prices, _ := PriceCheckMany(context.Background(), []int{7}); prices[7].String() != "17.99 EUR" {
+		t.Fatalf("PriceCheckMany(7) = %v", prices)
+	}
+	if err := SetPrice(7, money.MustParse("15", money.EUR)); err != nil {
+		t.Fatal(err)
+	}
+	if prices, _ := PriceCheckMany(context.Background(), []int{7}); prices[7].String() != "15.00 EUR" {
+		t.Errorf("PriceCheckMany(7) after SetPrice = %v, want 15.00 EUR", prices)
+	}
+}
@@ -0,0 +1,205 @@
+package promo
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"example.com/money"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidRule is wrapped by the errors of New and Load for a rule that
+// can't be used.
+var ErrInvalidRule = errors.New("invalid promotion rule")
+
+// File is a promotions file. In YAML:
+//
+//	currency: USD            # of the amounts below, USD if left out
+//	rules:
+//	  - name: bulk-cds
+//	    type: tiered
+//	    items: [2, 3]        # all items if left out
+//	    tiers:
+//	      - {min_quantity: 10, unit_price: "15.00"}
+//	      - {min_quantity: 50, unit_price: "12.50"}
+//	  - name: jeru-3-for-2
+//	    type: buy_x_get_y
+//	    items: [2]
+//	    buy: 2
+//	    get: 1
+//	  - name: summer-sale
+//	    type: percent_off
+//	    percent: 10
+//	    valid_from: 2026-06-01T00:00:00Z
+//	    valid_until: 2026-09-01T00:00:00Z
+//	  - name: save5
+//	    type: amount_off
+//	    amount: "5.00"
+//	    coupon: SAVE5
+//	    min_subtotal: "50.00"
+//
+// JSON files have the same fields.
+type File struct {
+	Currency money.Currency `json:"currency" yaml:"currency"`
+	Rules    []Rule         `json:"rules" yaml:"rules"`
+}
+
+// Rule is a promotion as written in a File. Which fields count depends on
+// Type; the conditions (Items, Coupon, the validity window and MinSubtotal)
+// work with every type.
+type Rule struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+
+	Items       []int     `json:"items,omitempty" yaml:"items,omitempty"`
+	Coupon      string    `json:"coupon,omitempty" yaml:"coupon,omitempty"`
+	ValidFrom   time.Time `json:"valid_from,omitzero" yaml:"valid_from,omitempty"`   // inclusive
+	ValidUntil  time.Time `json:"valid_until,omitzero" yaml:"valid_until,omitempty"` // exclusive
+	MinSubtotal Decimal   `json:"min_subtotal,omitempty" yaml:"min_subtotal,omitempty"`
+
+	Percent int64   `json:"percent,omitempty" yaml:"percent,omitempty"` // percent_off
+	Amount  Decimal `json:"amount,omitempty" yaml:"amount,omitempty"`   // amount_off
+	Buy     int64   `json:"buy,omitempty" yaml:"buy,omitempty"`         // buy_x_get_y
+	Get     int64   `json:"get,omitempty" yaml:"get,omitempty"`         // buy_x_get_y
+	Tiers   []Tier  `json:"tiers,omitempty" yaml:"tiers,omitempty"`     // tiered
+}
+
+// Tier is a unit price for buying at least MinQuantity units.
+type Tier struct {
+	MinQuantity int64   `json:"min_quantity" yaml:"min_quantity"`
+	UnitPrice   Decimal `json:"unit_price" yaml:"unit_price"`
+}
+
+// Decimal is an amount in a File, such as "5.00". It may be written as a
+// string or, in files that aren't read through a float, as a number.
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&n); err != nil {
+		return err
+	}
+	*d = Decimal(n)
+	return nil
+}
+
+// Load reads the promotions file at path, YAML if it ends in .yaml or .yml
+// and JSON otherwise.
+func Load(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := "json"
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		format = "yaml"
+	}
+	e, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return e, nil
+}
+
+// Parse reads a promotions file in format, "json" or "yaml".
+func Parse(data []byte, format string) (*Engine, error) {
+	var f File
+	var err error
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&f)
+	case "yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&f)
+	default:
+		return nil, fmt.Errorf("unknown format %q, want json or yaml", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.Currency == "" {
+		f.Currency = money.USD
+	}
+	c, err := money.ParseCurrency(string(f.Currency))
+	if err != nil {
+		return nil, err
+	}
+	return New(f.Rules, c)
+}
+
+// compile checks r and parses its amounts as c.
+func compile(r Rule, c money.Currency) (rule, error) {
+	invalid := func(format string, args ...any) (rule, error) {
+		return rule{}, fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+	}
+	parse := func(field string, d Decimal) (money.Amount, error) {
+		a, err := money.Parse(string(d), c)
+		if err != nil {
+			return money.Amount{}, fmt.Errorf("%w: %s: %w", ErrInvalidRule, field, err)
+		}
+		if a.IsNegative() {
+			return money.Amount{}, fmt.Errorf("%w: %s must not be negative", ErrInvalidRule, field)
+		}
+		return a, nil
+	}
+
+	compiled := rule{Rule: r}
+	var err error
+	if r.Name == "" {
+		return invalid("name is required")
+	}
+	if !r.ValidUntil.IsZero() && !r.ValidUntil.After(r.ValidFrom) {
+		return invalid("valid_until must be after valid_from")
+	}
+	if r.MinSubtotal != "" {
+		if compiled.minSubtotal, err = parse("min_subtotal", r.MinSubtotal); err != nil {
+			return rule{}, err
+		}
+	}
+
+	switch r.Type {
+	case PercentOff:
+		if r.Percent <= 0 || r.Percent > 100 {
+			return invalid("percent must be between 1 and 100")
+		}
+	case AmountOff:
+		if compiled.amount, err = parse("amount", r.Amount); err != nil {
+			return rule{}, err
+		}
+	case BuyXGetY:
+		if r.Buy <= 0 || r.Get <= 0 {
+			return invalid("buy and get must be positive")
+		}
+	case Tiered:
+		if len(r.Tiers) == 0 {
+			return invalid("tiers are required")
+		}
+		for i, t := range r.Tiers {
+			if t.MinQuantity <= 0 {
+				return invalid("tier %d: min_quantity must be positive", i+1)
+			}
+			price, err := parse(fmt.Sprintf("tier %d: unit_price", i+1), t.UnitPrice)
+			if err != nil {
+				return rule{}, err
+			}
+			compiled.tiers = append(compiled.tiers, tier{minQuantity: t.MinQuantity, unitPrice: price})
+		}
+		slices.SortFunc(compiled.tiers, func(a, b tier) int { return cmp.Compare(b.minQuantity, a.minQuantity) })
+	default:
+		return invalid("unknown type %q", r.Type)
+	}
+	return compiled, nil
+}
@@ -0,0 +1,265 @@
+// Package promo prices shopping items with promotions: percentage off,
+// amount off, buy X get Y free, tiered quantity pricing and coupons, each
+// optionally limited to some items and a validity window.
+//
+//	e, err := promo.Load("promotions.yaml")
+//	r, err := e.Price(promo.Item{ID: 2, Quantity: 3, UnitPrice: price}, promo.CartContext{Coupons: []string{"SAVE5"}})
+//	fmt.Println(r.Final, r.Applied)
+package promo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"moduleDemo/shopping"
+	"moduleDemo/shopping/cart"
+
+	"example.com/money"
+)
+
+// Rule types, the "type" of a rule in a file.
+const (
+	PercentOff = "percent_off" // Percent off the line, or off the cart for a coupon without items
+	AmountOff  = "amount_off"  // Amount off the line, or off the cart without items
+	BuyXGetY   = "buy_x_get_y" // of every Buy+Get units, Get are free
+	Tiered     = "tiered"      // the unit price of the highest tier reached
+)
+
+// Item is the line being priced: Quantity units of item ID.
+type Item struct {
+	ID        int
+	Quantity  int64
+	UnitPrice money.Amount
+}
+
+// CartContext is what rules know about the rest of the cart.
+type CartContext struct {
+	Subtotal money.Amount       // of the whole cart, for min_subtotal
+	Coupons  []string           // codes the shopper entered
+	Now      time.Time          // for validity windows; the zero Time means time.Now()
+	Rounding money.RoundingMode // for percent_off; the zero value is money.HalfEven
+}
+
+// Result is the price of an Item after promotions.
+type Result struct {
+	Base    money.Amount // UnitPrice * Quantity
+	Final   money.Amount // Base less every Applied.Off, never below zero
+	Applied []Applied    // in the order the rules were applied
+}
+
+// Applied is a rule that changed the price.
+type Applied struct {
+	Rule string       // its name
+	Type string       // PercentOff, AmountOff, ...
+	Off  money.Amount // what it took off
+}
+
+// Engine applies a list of rules. Rules apply in order, each to the price
+// left by the rules before it, so list tiered prices first and fixed
+// amounts last. An Engine is safe for concurrent use.
+type Engine struct {
+	rules []rule
+}
+
+// New returns an Engine for rules, which are checked as by Load.
+func New(rules []Rule, currency money.Currency) (*Engine, error) {
+	e := &Engine{}
+	for i, r := range rules {
+		compiled, err := compile(r, currency)
+		if err != nil {
+			name := r.Name
+			if name == "" {
+				name = fmt.Sprint("#", i+1)
+			}
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// Price applies the rules to item, priced on its own as if it were the whole
+// cart, so cart-level rules apply to it too.
+func (e *Engine) Price(item Item, ctx CartContext) (Result, error) {
+	return e.price(item, ctx, true)
+}
+
+// price applies the rules to item, leaving out the cart-level ones unless
+// cartLevel is set.
+func (e *Engine) price(item Item, ctx CartContext, cartLevel bool) (Result, error) {
+	base, err := item.UnitPrice.Mul(item.Quantity)
+	if err != nil {
+		return Result{}, fmt.Errorf("item %d: %w", item.ID, err)
+	}
+	if ctx.Now.IsZero() {
+		ctx.Now = time.Now()
+	}
+	l := line{Item: item, total: base}
+	res := Result{Base: base}
+	for _, r := range e.rules {
+		if r.cartLevel() && !cartLevel || !r.matches(item, ctx) {
+			continue
+		}
+		off, err := r.apply(&l, ctx.Rounding)
+		if err != nil {
+			return Result{}, fmt.Errorf("rule %s, item %d: %w", r.Name, item.ID, err)
+		}
+		if off.IsZero() {
+			continue
+		}
+		res.Applied = append(res.Applied, Applied{Rule: r.Name, Type: r.Type, Off: off})
+	}
+	res.Final = l.total
+	return res, nil
+}
+
+// PriceCheck is shopping.PriceCheck with the promotions for quantity units
+// of the item applied. It returns false if there is no such item.
+func (e *Engine) PriceCheck(itemID int, quantity int64, ctx CartContext) (Result, bool, error) {
+	price, ok := shopping.PriceCheck(itemID)
+	if !ok {
+		return Result{}, false, nil
+	}
+	r, err := e.Price(Item{ID: itemID, Quantity: quantity, UnitPrice: price}, ctx)
+	return r, true, err
+}
+
+// Discount adapts the engine to a cart.Discount, with coupons entered. The
+// line rules are applied to each line, and then the cart-level rules once,
+// in order, to what is left of the subtotal. Percentages are rounded with
+// the cart's rounding mode.
+func (e *Engine) Discount(coupons ...string) cart.Discount {
+	return cart.DiscountFunc(func(lines []cart.Line, subtotal money.Amount, mode money.RoundingMode) (money.Amount, error) {
+		ctx := CartContext{Subtotal: subtotal, Coupons: coupons, Now: time.Now(), Rounding: mode}
+		rest := line{Item: Item{UnitPrice: money.Zero(subtotal.Currency())}, total: money.Zero(subtotal.Currency())}
+		for _, l := range lines {
+			r, err := e.price(Item{ID: l.ItemID, Quantity: l.Quantity, UnitPrice: l.UnitPrice}, ctx, false)
+			if err != nil {
+				return money.Amount{}, err
+			}
+			if rest.total, err = rest.total.Add(r.Final); err != nil {
+				return money.Amount{}, err
+			}
+		}
+		for _, r := range e.rules {
+			if !r.cartLevel() || !r.matches(rest.Item, ctx) {
+				continue
+			}
+			if _, err := r.apply(&rest, mode); err != nil {
+				return money.Amount{}, fmt.Errorf("rule %s: %w", r.Name, err)
+			}
+		}
+		return subtotal.Sub(rest.total)
+	})
+}
+
+// rule is a Rule with its amounts parsed.
+type rule struct {
+	Rule
+	amount      money.Amount
+	minSubtotal money.Amount
+	tiers       []tier // by MinQuantity, highest first
+}
+
+type tier struct {
+	minQuantity int64
+	unitPrice   money.Amount
+}
+
+// line is an Item being priced, with its total so far.
+type line struct {
+	Item
+	total money.Amount
+}
+
+// cartLevel reports whether r applies to a cart's subtotal rather than to
+// each line: an amount_off, or a percent_off that needs a coupon, that
+// isn't limited to some items.
+func (r *rule) cartLevel() bool {
+	return len(r.Items) == 0 && (r.Type == AmountOff || r.Type == PercentOff && r.Coupon != "")
+}
+
+// matches reports whether r's conditions hold for item in ctx.
+func (r *rule) matches(item Item, ctx CartContext) bool {
+	if len(r.Items) > 0 && !slices.Contains(r.Items, item.ID) {
+		return false
+	}
+	if r.Coupon != "" && !slices.ContainsFunc(ctx.Coupons, func(c string) bool { return strings.EqualFold(c, r.Coupon) }) {
+		return false
+	}
+	if !r.ValidFrom.IsZero() && ctx.Now.Before(r.ValidFrom) {
+		return false
+	}
+	if !r.ValidUntil.IsZero() && !ctx.Now.Before(r.ValidUntil) {
+		return false
+	}
+	// Amounts in the rule only make sense for items in the same currency.
+	c := item.UnitPrice.Currency()
+	if (r.Type == AmountOff || r.Type == Tiered || !r.minSubtotal.IsZero()) && c != r.currency() {
+		return false
+	}
+	if !r.minSubtotal.IsZero() {
+		if cmp, err := ctx.Subtotal.Cmp(r.minSubtotal); err != nil || cmp < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *rule) currency() money.Currency {
+	switch {
+	case r.Type == AmountOff:
+		return r.amount.Currency()
+	case r.Type == Tiered:
+		return r.tiers[0].unitPrice.Currency()
+	}
+	return r.minSubtotal.Currency()
+}
+
+// apply takes r off l.total and returns how much it took off. Percentages
+// are rounded with mode.
+func (r *rule) apply(l *line, mode money.RoundingMode) (money.Amount, error) {
+	var off money.Amount
+	var err error
+	switch r.Type {
+	case PercentOff:
+		off, err = l.total.MulFrac(r.Percent, 100, mode)
+	case AmountOff:
+		off = r.amount
+	case BuyXGetY:
+		free := l.Quantity / (r.Buy + r.Get) * r.Get
+		off, err = l.UnitPrice.Mul(free)
+	case Tiered:
+		for _, t := range r.tiers {
+			if l.Quantity < t.minQuantity {
+				continue
+			}
+			var tierTotal money.Amount
+			if tierTotal, err = t.unitPrice.Mul(l.Quantity); err != nil {
+				break
+			}
+			if off, err = l.total.Sub(tierTotal); err == nil && !off.IsNegative() {
+				l.UnitPrice = t.unitPrice // for rules after this one
+			} else {
+				off = money.Zero(l.total.Currency()) // dearer than the price so far
+			}
+			break
+		}
+	}
+	if err != nil {
+		return money.Amount{}, err
+	}
+
+	// Never take off more than is left.
+	if cmp, err := off.Cmp(l.total); err != nil {
+		return money.Amount{}, err
+	} else if cmp > 0 {
+		off = l.total
+	}
+	if l.total, err = l.total.Sub(off); err != nil {
+		return money.Amount{}, err
+	}
+	return off, nil
+}
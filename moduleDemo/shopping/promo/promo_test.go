@@ -0,0 +1,156 @@
+package promo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"moduleDemo/shopping/cart"
+
+	"example.com/money"
+)
+
+const rulesYAML = `
+rules:
+  - name: bulk-cds
+    type: tiered
+    items: [2]
+    tiers:
+      - {min_quantity: 10, unit_price: 15.00}
+      - {min_quantity: 50, unit_price: "12.50"}
+  - name: jeru-3-for-2
+    type: buy_x_get_y
+    items: [2]
+    buy: 2
+    get: 1
+  - name: summer-sale
+    type: percent_off
+    percent: 10
+    valid_from: 2026-06-01T00:00:00Z
+    valid_until: 2026-09-01T00:00:00Z
+  - name: save5
+    type: amount_off
+    amount: "5.00"
+    coupon: SAVE5
+    min_subtotal: "50.00"
+`
+
+func usd(s string) money.Amount { return money.MustParse(s, money.USD) }
+
+func TestPrice(t *testing.T) {
+	e, err := Parse([]byte(rulesYAML), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	summer := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	winter := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	jeru := usd("17.99")
+
+	tests := []struct {
+		name    string
+		item    Item
+		ctx     CartContext
+		final   string
+		applied []string
+	}{
+		{"no rules apply", Item{ID: 1, Quantity: 1, UnitPrice: usd("56.99")}, CartContext{Now: winter}, "56.99", nil},
+		{"3 for 2", Item{ID: 2, Quantity: 3, UnitPrice: jeru}, CartContext{Now: winter}, "35.98", []string{"jeru-3-for-2"}},
+		// 12 at 15.00 is 180.00, 4 of them free is 120.00.
+		{"tier then 3 for 2", Item{ID: 2, Quantity: 12, UnitPrice: jeru}, CartContext{Now: winter}, "120.00", []string{"bulk-cds", "jeru-3-for-2"}},
+		{"highest tier", Item{ID: 2, Quantity: 50, UnitPrice: jeru}, CartContext{Now: winter}, "425.00", []string{"bulk-cds", "jeru-3-for-2"}},
+		{"in the window", Item{ID: 1, Quantity: 1, UnitPrice: usd("56.99")}, CartContext{Now: summer}, "51.29", []string{"summer-sale"}},
+		{"coupon", Item{ID: 1, Quantity: 1, UnitPrice: usd("56.99")},
+			CartContext{Now: winter, Coupons: []string{"save5"}, Subtotal: usd("56.99")}, "51.99", []string{"save5"}},
+		{"coupon below min_subtotal", Item{ID: 1, Quantity: 1, UnitPrice: usd("9.99")},
+			CartContext{Now: winter, Coupons: []string{"SAVE5"}, Subtotal: usd("9.99")}, "9.99", nil},
+		{"amounts need the same currency", Item{ID: 1, Quantity: 1, UnitPrice: money.MustParse("1000", money.JPY)},
+			CartContext{Now: summer, Coupons: []string{"SAVE5"}, Subtotal: usd("100")}, "900", []string{"summer-sale"}},
+	}
+	for _, tt := range tests {
+		r, err := e.Price(tt.item, tt.ctx)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var applied []string
+		sum := r.Final
+		for _, a := range r.Applied {
+			applied = append(applied, a.Rule)
+			sum, _ = sum.Add(a.Off)
+		}
+		if r.Final.Decimal() != tt.final || strings.Join(applied, ",") != strings.Join(tt.applied, ",") {
+			t.Errorf("%s: Price = %v after %v, want %s after %v", tt.name, r.Final, applied, tt.final, tt.applied)
+		}
+		if sum != r.Base {
+			t.Errorf("%s: Final %v plus the breakdown = %v, want Base %v", tt.name, r.Final, sum, r.Base)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "promotions.json")
+	os.WriteFile(path, []byte(`{"currency": "usd", "rules": [{"name": "half", "type": "amount_off", "amount": 0.50}]}`), 0o644)
+	e, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := e.Price(Item{ID: 1, Quantity: 1, UnitPrice: usd("1")}, CartContext{}); err != nil || r.Final != usd("0.50") {
+		t.Errorf("Price = %+v, %v; want 0.50", r, err)
+	}
+
+	for _, bad := range []string{
+		`{"rules": [{"name": "x", "type": "percent_off", "percent": 101}]}`,
+		`{"rules": [{"name": "x", "type": "amount_off", "amount": "0.001"}]}`,
+		`{"rules": [{"name": "x", "type": "buy_x_get_y", "buy": 2}]}`,
+		`{"rules": [{"name": "x", "type": "mystery"}]}`,
+		`{"rules": [{"type": "percent_off", "percent": 5}]}`,
+	} {
+		if _, err := Parse([]byte(bad), "json"); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%s) error = %v, want ErrInvalidRule", bad, err)
+		}
+	}
+	if _, err := Parse([]byte(`{"rules": [{"name": "x", "type": "percent_off", "percnt": 5}]}`), "json"); err == nil {
+		t.Error("Parse accepted a misspelt field")
+	}
+}
+
+func TestCartDiscount(t *testing.T) {
+	e, err := Parse([]byte(rulesYAML), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices := map[int]money.Amount{1: usd("56.99"), 2: usd("17.99")}
+	c := cart.New(
+		cart.WithPricer(func(id int) (money.Amount, bool) { a, ok := prices[id]; return a, ok }),
+		cart.WithDiscount(e.Discount("SAVE5")),
+	)
+	c.Add(1, 1)
+	c.Add(2, 3)
+	totals, err := c.Totals()
+	// 56.99 + 53.97 = 110.96, less 17.99 (3 for 2) and 5.00 off the cart once.
+	if err != nil || totals.Discount != usd("22.99") {
+		t.Errorf("Totals = %+v, %v; want 22.99 off", totals, err)
+	}
+}
+
+func TestCartDiscountRounding(t *testing.T) {
+	e, err := Parse([]byte(`{"rules": [{"name": "ten", "type": "percent_off", "percent": 10, "coupon": "TEN"}]}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for mode, want := range map[money.RoundingMode]string{money.HalfEven: "0.02", money.Up: "0.03", money.Down: "0.02"} {
+		c := cart.New(
+			cart.WithPricer(func(int) (money.Amount, bool) { return usd("0.05"), true }),
+			cart.WithDiscount(e.Discount("TEN")),
+			cart.WithRounding(mode),
+		)
+		c.Add(1, 5) // 0.25, 10% of which is 0.025
+		if totals, err := c.Totals(); err != nil || totals.Discount != usd(want) {
+			t.Errorf("%v: Totals = %+v, %v; want %s off", mode, totals, err, want)
+		}
+	}
+}
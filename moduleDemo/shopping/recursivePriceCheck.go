@@ -1,13 +0,0 @@
-package shopping
-
-import (
-	"moduleDemo/shopping/db"
-)
-
-func PriceCheck2(itemId int) (float64, bool) {
-	item := db.LoadItem(itemId)
-	if item == nil {
-		return 0, false
-	}
-	return item.Price, true
-}
@@ -0,0 +1,52 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code such as "USD".
+type Currency string
+
+// Currencies known to this package.
+const (
+	USD Currency = "USD" // US dollar, 2 decimals
+	EUR Currency = "EUR" // euro, 2 decimals
+	GBP Currency = "GBP" // pound sterling, 2 decimals
+	CHF Currency = "CHF" // Swiss franc, 2 decimals
+	CNY Currency = "CNY" // renminbi, 2 decimals
+	JPY Currency = "JPY" // yen, no minor unit
+	KWD Currency = "KWD" // Kuwaiti dinar, 3 decimals
+)
+
+// DefaultCurrency is the currency Scan assumes when neither the database
+// value nor the destination Amount names one. Every price in this
+// repository is in US dollars.
+const DefaultCurrency = USD
+
+// digits is the number of decimal places of each known currency's minor unit.
+var digits = map[Currency]int{
+	USD: 2, EUR: 2, GBP: 2, CHF: 2, CNY: 2,
+	JPY: 0,
+	KWD: 3,
+}
+
+// ParseCurrency returns the Currency for code, ignoring case.
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := digits[c]; !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+	return c, nil
+}
+
+// Digits returns the number of decimal places of c's minor unit: 2 for USD
+// (cents), 0 for JPY. It is 0 for the empty currency of a zero Amount.
+func (c Currency) Digits() int {
+	return digits[c]
+}
+
+func (c Currency) known() bool {
+	_, ok := digits[c]
+	return ok
+}
@@ -0,0 +1,99 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// amountJSON is the JSON form of an Amount. The amount is a string so that
+// JavaScript clients, whose numbers are float64, don't round it.
+type amountJSON struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON encodes a as {"amount": "56.99", "currency": "USD"}, and the
+// zero Amount as null.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if a.currency == "" {
+		return []byte("null"), nil
+	}
+	return json.Marshal(amountJSON{Amount: a.Decimal(), Currency: a.currency})
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	var v amountJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	c, err := ParseCurrency(string(v.Currency))
+	if err != nil {
+		return err
+	}
+	parsed, err := Parse(v.Amount, c)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value stores a in a DECIMAL column as a string such as "56.99", which
+// databases convert without going through a float. The currency isn't
+// stored; keep it in a column of its own if it varies.
+func (a Amount) Value() (driver.Value, error) {
+	return a.Decimal(), nil
+}
+
+// Scan reads a DECIMAL, INTEGER, REAL or text column. The currency is the
+// one a already has, else a currency code after the number ("56.99 USD"),
+// else DefaultCurrency. NULL is an error; scan into a *Amount or
+// sql.Null[Amount] for a nullable column.
+func (a *Amount) Scan(src any) error {
+	c := a.currency
+	var text string
+	switch v := src.(type) {
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		// SQLite returns DECIMAL as REAL. The shortest form that reads back
+		// as v, such as "56.99", is the decimal that was stored.
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case nil:
+		return fmt.Errorf("money: can't scan NULL into an Amount")
+	default:
+		return fmt.Errorf("money: can't scan %T into an Amount", src)
+	}
+
+	if number, code, ok := strings.Cut(strings.TrimSpace(text), " "); ok {
+		parsed, err := ParseCurrency(code)
+		if err != nil {
+			return err
+		}
+		if c != "" && c != parsed {
+			return fmt.Errorf("%w: scanning %s into %s", ErrCurrencyMismatch, parsed, c)
+		}
+		text, c = number, parsed
+	}
+	if c == "" {
+		c = DefaultCurrency
+	}
+	parsed, err := Parse(text, c)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
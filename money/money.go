@@ -0,0 +1,318 @@
+// Package money represents amounts of money exactly, as a whole number of
+// minor units (cents for USD) of an ISO 4217 currency.
+//
+// A float64 can't hold most decimal fractions: 56.99 is really
+// 56.98999999999999488409, and 0.1+0.2 != 0.3. An Amount of 56.99 USD is
+// the integer 5699 and the currency USD, so sums and comparisons are exact,
+// and rounding happens only where a RoundingMode says so.
+//
+//	price := money.MustParse("56.99", money.USD)
+//	total, err := price.Mul(3)                           // 170.97 USD
+//	off, err := total.MulFrac(85, 100, money.HalfEven)   // 15% off: 145.32 USD
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by this package. They are wrapped, so test them with errors.Is.
+var (
+	ErrSyntax           = errors.New("money: invalid amount")
+	ErrPrecision        = errors.New("money: more decimal places than the currency has")
+	ErrOverflow         = errors.New("money: amount out of range")
+	ErrCurrencyMismatch = errors.New("money: currencies differ")
+	ErrUnknownCurrency  = errors.New("money: unknown currency")
+)
+
+// Amount is an exact amount of money. Amounts are values: the methods
+// return a new Amount rather than changing the receiver.
+//
+// The zero Amount has no currency and acts as zero of any currency in Add,
+// Sub and Cmp, so it can start a running total.
+type Amount struct {
+	minor    int64 // in units of 10^-currency.Digits()
+	currency Currency
+}
+
+// New returns minor minor units of c, e.g. New(5699, USD) is 56.99 USD.
+func New(minor int64, c Currency) Amount {
+	return Amount{minor: minor, currency: c}
+}
+
+// Zero returns no money in c.
+func Zero(c Currency) Amount {
+	return Amount{currency: c}
+}
+
+// Parse reads a decimal such as "56.99", "-3" or "0.50" as an amount of c.
+// It returns an error wrapping ErrPrecision if s has more decimal places
+// than c (other than trailing zeros), rather than rounding silently.
+func Parse(s string, c Currency) (Amount, error) {
+	return parse(s, c, nil)
+}
+
+// ParseRound is Parse, except that extra decimal places are rounded with mode.
+// ParseRound("9.005", USD, HalfEven) is 9.00 USD.
+func ParseRound(s string, c Currency, mode RoundingMode) (Amount, error) {
+	return parse(s, c, &mode)
+}
+
+// MustParse is Parse for amounts known to be valid, such as constants in
+// code. It panics on error.
+func MustParse(s string, c Currency) Amount {
+	a, err := Parse(s, c)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func parse(s string, c Currency, mode *RoundingMode) (Amount, error) {
+	if !c.known() {
+		return Amount{}, fmt.Errorf("%w %q", ErrUnknownCurrency, c)
+	}
+	text := strings.TrimSpace(s)
+	neg := false
+	if text != "" && (text[0] == '-' || text[0] == '+') {
+		neg = text[0] == '-'
+		text = text[1:]
+	}
+	whole, frac, hasPoint := strings.Cut(text, ".")
+	if whole == "" || (hasPoint && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return Amount{}, fmt.Errorf("%w %q, want a decimal such as 56.99", ErrSyntax, s)
+	}
+
+	d := c.Digits()
+	var extra string // decimal places beyond d
+	if len(frac) > d {
+		frac, extra = frac[:d], frac[d:]
+	}
+	frac += strings.Repeat("0", d-len(frac))
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+
+	if strings.Trim(extra, "0") != "" {
+		if mode == nil {
+			return Amount{}, fmt.Errorf("%w: %q, %s has %d", ErrPrecision, s, c, d)
+		}
+		// Compare the dropped digits with one half, 0.5000...
+		half := "5" + strings.Repeat("0", len(extra)-1)
+		if roundsAway(*mode, strings.Compare(extra, half), neg, minor%2 != 0) {
+			if minor == math.MaxInt64 {
+				return Amount{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+			}
+			minor++
+		}
+	}
+	if neg {
+		minor = -minor
+	}
+	return Amount{minor: minor, currency: c}, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor returns a in minor units, e.g. 5699 for 56.99 USD.
+func (a Amount) Minor() int64 { return a.minor }
+
+// Currency returns the currency of a.
+func (a Amount) Currency() Currency { return a.currency }
+
+// IsZero reports whether a is no money.
+func (a Amount) IsZero() bool { return a.minor == 0 }
+
+// IsNegative reports whether a is less than zero.
+func (a Amount) IsNegative() bool { return a.minor < 0 }
+
+// Sign returns -1, 0 or +1 as a is negative, zero or positive.
+func (a Amount) Sign() int {
+	switch {
+	case a.minor < 0:
+		return -1
+	case a.minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// same returns the common currency of a and b. A zero Amount without a
+// currency matches any currency.
+func (a Amount) same(b Amount) (Currency, error) {
+	switch {
+	case a.currency == b.currency:
+		return a.currency, nil
+	case a.currency == "" && a.minor == 0:
+		return b.currency, nil
+	case b.currency == "" && b.minor == 0:
+		return a.currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.currency, b.currency)
+}
+
+// Add returns a+b. Both must be in the same currency.
+func (a Amount) Add(b Amount) (Amount, error) {
+	c, err := a.same(b)
+	if err != nil {
+		return Amount{}, err
+	}
+	sum := a.minor + b.minor
+	// Overflow wraps around, flipping the sign of two operands with the same sign.
+	if (a.minor > 0 && b.minor > 0 && sum < 0) || (a.minor < 0 && b.minor < 0 && sum >= 0) {
+		return Amount{}, fmt.Errorf("%w: %s + %s", ErrOverflow, a, b)
+	}
+	return Amount{minor: sum, currency: c}, nil
+}
+
+// Sub returns a-b. Both must be in the same currency.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b.minor == math.MinInt64 {
+		return Amount{}, fmt.Errorf("%w: %s - %s", ErrOverflow, a, b)
+	}
+	return a.Add(b.Neg())
+}
+
+// Neg returns -a.
+func (a Amount) Neg() Amount {
+	return Amount{minor: -a.minor, currency: a.currency}
+}
+
+// Abs returns |a|.
+func (a Amount) Abs() Amount {
+	if a.minor < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// Mul returns a*n, e.g. the price of n items.
+func (a Amount) Mul(n int64) (Amount, error) {
+	return a.MulFrac(n, 1, HalfEven) // exact, so the mode doesn't matter
+}
+
+// MulFrac returns a*num/den rounded to a minor unit with mode. A 15%
+// discount is a.MulFrac(15, 100, mode), the discounted price
+// a.MulFrac(85, 100, mode).
+func (a Amount) MulFrac(num, den int64, mode RoundingMode) (Amount, error) {
+	if den == 0 {
+		return Amount{}, fmt.Errorf("money: %s * %d/0: division by zero", a, num)
+	}
+	product := new(big.Int).Mul(big.NewInt(a.minor), big.NewInt(num))
+	q := divRound(product, big.NewInt(den), mode)
+	if !q.IsInt64() {
+		return Amount{}, fmt.Errorf("%w: %s * %d/%d", ErrOverflow, a, num, den)
+	}
+	return Amount{minor: q.Int64(), currency: a.currency}, nil
+}
+
+// Round rounds a to places decimal places with mode, e.g. to whole dollars
+// with places 0. It returns a unchanged if its currency has no more places.
+func (a Amount) Round(places int, mode RoundingMode) (Amount, error) {
+	d := a.currency.Digits()
+	if places >= d {
+		return a, nil
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d-places)), nil)
+	q := divRound(big.NewInt(a.minor), unit, mode)
+	if q.Mul(q, unit); !q.IsInt64() {
+		return Amount{}, fmt.Errorf("%w: %s rounded to %d places", ErrOverflow, a, places)
+	}
+	return Amount{minor: q.Int64(), currency: a.currency}, nil
+}
+
+// Allocate splits a into len(ratios) parts in proportion to ratios, without
+// losing or creating a minor unit: the parts always add up to a. Units left
+// over from rounding go one each to the first parts, so Allocate(1, 1, 1)
+// splits 100.00 into 33.34, 33.33 and 33.33.
+func (a Amount) Allocate(ratios ...int64) ([]Amount, error) {
+	var total int64
+	for _, r := range ratios {
+		if r < 0 || total+r < total {
+			return nil, fmt.Errorf("money: Allocate ratios %v must be non-negative and not overflow", ratios)
+		}
+		total += r
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("money: Allocate needs a positive ratio")
+	}
+
+	parts := make([]Amount, len(ratios))
+	left := a.minor
+	for i, r := range ratios {
+		share := new(big.Int).Mul(big.NewInt(a.minor), big.NewInt(r))
+		share.Quo(share, big.NewInt(total)) // truncated, so |share| <= |a|
+		parts[i] = Amount{minor: share.Int64(), currency: a.currency}
+		left -= parts[i].minor
+	}
+	step := int64(1)
+	if left < 0 {
+		step = -1
+	}
+	for i := 0; left != 0; i++ {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i].minor += step
+		left -= step
+	}
+	return parts, nil
+}
+
+// Cmp returns -1, 0 or +1 as a is less than, equal to or greater than b.
+// Both must be in the same currency.
+func (a Amount) Cmp(b Amount) (int, error) {
+	if _, err := a.same(b); err != nil {
+		return 0, err
+	}
+	switch {
+	case a.minor < b.minor:
+		return -1, nil
+	case a.minor > b.minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Decimal returns a as a decimal with the places of its currency, without
+// the currency, e.g. "56.99", "-0.50" or "1000" for JPY.
+func (a Amount) Decimal() string {
+	d := a.currency.Digits()
+	// Go through uint64 so the magnitude of math.MinInt64 can be taken.
+	mag := uint64(a.minor)
+	if a.minor < 0 {
+		mag = -mag
+	}
+	digits := strconv.FormatUint(mag, 10)
+	if len(digits) <= d {
+		digits = strings.Repeat("0", d-len(digits)+1) + digits
+	}
+	s := digits
+	if d > 0 {
+		s = digits[:len(digits)-d] + "." + digits[len(digits)-d:]
+	}
+	if a.minor < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// String returns a with its currency, e.g. "56.99 USD". The zero Amount is "0".
+func (a Amount) String() string {
+	if a.currency == "" {
+		return a.Decimal()
+	}
+	return a.Decimal() + " " + string(a.currency)
+}
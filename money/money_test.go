@@ -0,0 +1,190 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in    string
+		c     Currency
+		minor int64
+		err   error
+	}{
+		{"56.99", USD, 5699, nil},
+		{"9", USD, 900, nil},
+		{"-0.5", USD, -50, nil},
+		{"+1.10", EUR, 110, nil},
+		{"56.990", USD, 5699, nil}, // trailing zeros are fine
+		{"1000", JPY, 1000, nil},
+		{"1.234", KWD, 1234, nil},
+		{"9.001", USD, 0, ErrPrecision},
+		{"1.5", JPY, 0, ErrPrecision},
+		{"", USD, 0, ErrSyntax},
+		{".5", USD, 0, ErrSyntax},
+		{"5.", USD, 0, ErrSyntax},
+		{"1e3", USD, 0, ErrSyntax},
+		{"1,000.00", USD, 0, ErrSyntax},
+		{"99999999999999999999", USD, 0, ErrOverflow},
+		{"1", "XYZ", 0, ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		a, err := Parse(tt.in, tt.c)
+		if !errors.Is(err, tt.err) || (err == nil && (a.Minor() != tt.minor || a.Currency() != tt.c)) {
+			t.Errorf("Parse(%q, %s) = %v, %v; want %d minor units, %v", tt.in, tt.c, a, err, tt.minor, tt.err)
+		}
+	}
+}
+
+func TestRounding(t *testing.T) {
+	// Each input rounded to cents with every mode, in the order of the
+	// RoundingMode constants: HalfEven, HalfUp, HalfDown, Down, Up, Floor, Ceiling.
+	tests := []struct {
+		in   string
+		want [7]int64
+	}{
+		{"2.125", [7]int64{212, 213, 212, 212, 213, 212, 213}},
+		{"2.135", [7]int64{214, 214, 213, 213, 214, 213, 214}},
+		{"2.1251", [7]int64{213, 213, 213, 212, 213, 212, 213}},
+		{"-2.125", [7]int64{-212, -213, -212, -212, -213, -213, -212}},
+		{"-2.121", [7]int64{-212, -212, -212, -212, -213, -213, -212}},
+	}
+	for _, tt := range tests {
+		for mode := HalfEven; mode <= Ceiling; mode++ {
+			a, err := ParseRound(tt.in, USD, mode)
+			if err != nil || a.Minor() != tt.want[mode] {
+				t.Errorf("ParseRound(%q, %v) = %v, %v; want %d", tt.in, mode, a.Minor(), err, tt.want[mode])
+			}
+		}
+	}
+
+	// MulFrac and Round must agree with ParseRound.
+	a := New(2125, KWD) // 2.125
+	for mode := HalfEven; mode <= Ceiling; mode++ {
+		r, err := a.Round(2, mode)
+		if err != nil || r.Minor() != tests[0].want[mode]*10 {
+			t.Errorf("Round(2.125 KWD, 2, %v) = %v, %v", mode, r, err)
+		}
+		m, err := New(21250, USD).MulFrac(1, 100, mode) // 212.50 cents
+		if err != nil || m.Minor() != []int64{212, 213, 212, 212, 213, 212, 213}[mode] {
+			t.Errorf("MulFrac(212.50, 1/100, %v) = %v, %v", mode, m, err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	price := MustParse("56.99", USD)
+
+	total, err := price.Mul(3)
+	if err != nil || total.String() != "170.97 USD" {
+		t.Errorf("56.99 * 3 = %v, %v", total, err)
+	}
+	discounted, err := total.MulFrac(85, 100, HalfEven)
+	if err != nil || discounted.Decimal() != "145.32" {
+		t.Errorf("170.97 * 85%% = %v, %v; want 145.32", discounted, err)
+	}
+
+	// The zero Amount starts a running total in any currency.
+	var sum Amount
+	for _, s := range []string{"0.10", "0.20"} {
+		if sum, err = sum.Add(MustParse(s, USD)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cmp, err := sum.Cmp(MustParse("0.30", USD)); cmp != 0 || err != nil {
+		t.Errorf("0.10 + 0.20 = %v, want 0.30 USD", sum)
+	}
+
+	if _, err := price.Add(MustParse("1", EUR)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD + EUR error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := New(math.MaxInt64, USD).Add(New(1, USD)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt64 + 1 error = %v, want ErrOverflow", err)
+	}
+	if _, err := New(math.MaxInt64, USD).Mul(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt64 * 2 error = %v, want ErrOverflow", err)
+	}
+	if d, err := New(-5, USD).Sub(New(10, USD)); err != nil || d.Decimal() != "-0.15" {
+		t.Errorf("-0.05 - 0.10 = %v, %v", d, err)
+	}
+	if got := New(math.MinInt64, USD).Decimal(); got != "-92233720368547758.08" {
+		t.Errorf("MinInt64 cents = %s", got)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		total  int64
+		ratios []int64
+		want   []int64
+	}{
+		{10000, []int64{1, 1, 1}, []int64{3334, 3333, 3333}},
+		{-10000, []int64{1, 1, 1}, []int64{-3334, -3333, -3333}},
+		{5, []int64{0, 3, 7}, []int64{0, 2, 3}},
+		{1000, []int64{70, 30}, []int64{700, 300}},
+	}
+	for _, tt := range tests {
+		parts, err := New(tt.total, USD).Allocate(tt.ratios...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range parts {
+			if p.Minor() != tt.want[i] {
+				t.Errorf("Allocate(%d, %v) = %v, want %v", tt.total, tt.ratios, parts, tt.want)
+				break
+			}
+		}
+	}
+	if _, err := New(1, USD).Allocate(0, 0); err == nil {
+		t.Error("Allocate with only zero ratios succeeded")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	b, err := json.Marshal(struct{ Price, Unset Amount }{Price: MustParse("9.5", USD)})
+	if want := `{"Price":{"amount":"9.50","currency":"USD"},"Unset":null}`; err != nil || string(b) != want {
+		t.Errorf("Marshal = %s, %v; want %s", b, err, want)
+	}
+
+	var a Amount
+	if err := json.Unmarshal([]byte(`{"amount":"1.234","currency":"kwd"}`), &a); err != nil || a.String() != "1.234 KWD" {
+		t.Errorf("Unmarshal = %v, %v; want 1.234 KWD", a, err)
+	}
+	if err := json.Unmarshal([]byte(`{"amount":"1.234","currency":"USD"}`), &a); !errors.Is(err, ErrPrecision) {
+		t.Errorf("Unmarshal of 1.234 USD error = %v, want ErrPrecision", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		dst  Amount
+		src  any
+		want string
+	}{
+		{Amount{}, []byte("56.99"), "56.99 USD"}, // MySQL DECIMAL
+		{Amount{}, 56.99, "56.99 USD"},           // SQLite REAL
+		{Amount{}, int64(17), "17.00 USD"},       // SQLite INTEGER
+		{Zero(JPY), "1000", "1000 JPY"},
+		{Amount{}, "9.50 EUR", "9.50 EUR"},
+	}
+	for _, tt := range tests {
+		a := tt.dst
+		if err := a.Scan(tt.src); err != nil || a.String() != tt.want {
+			t.Errorf("Scan(%#v) = %v, %v; want %s", tt.src, a, err, tt.want)
+		}
+	}
+
+	a := Zero(USD)
+	if err := a.Scan("1 EUR"); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Scan of EUR into USD error = %v, want ErrCurrencyMismatch", err)
+	}
+	if err := a.Scan(nil); err == nil {
+		t.Error("Scan(nil) succeeded")
+	}
+	if v, err := MustParse("0.5", USD).Value(); err != nil || v != "0.50" {
+		t.Errorf("Value = %v, %v; want 0.50", v, err)
+	}
+}
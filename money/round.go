@@ -0,0 +1,93 @@
+package money
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// RoundingMode says which way a result between two minor units goes.
+// The examples round to whole units.
+type RoundingMode int
+
+const (
+	// HalfEven rounds to the nearest unit, and a tie to the even one:
+	// 2.5 → 2, 3.5 → 4. Also called banker's rounding, it doesn't drift
+	// upwards over many roundings. It is the zero RoundingMode.
+	HalfEven RoundingMode = iota
+	// HalfUp rounds to the nearest unit, and a tie away from zero:
+	// 2.5 → 3, -2.5 → -3. This is the rounding taught at school.
+	HalfUp
+	// HalfDown rounds to the nearest unit, and a tie towards zero:
+	// 2.5 → 2, -2.5 → -2.
+	HalfDown
+	// Down truncates towards zero: 2.9 → 2, -2.9 → -2.
+	Down
+	// Up rounds away from zero: 2.1 → 3, -2.1 → -3.
+	Up
+	// Floor rounds towards negative infinity: 2.9 → 2, -2.1 → -3.
+	Floor
+	// Ceiling rounds towards positive infinity: 2.1 → 3, -2.9 → -2.
+	Ceiling
+)
+
+func (m RoundingMode) String() string {
+	switch m {
+	case HalfEven:
+		return "HalfEven"
+	case HalfUp:
+		return "HalfUp"
+	case HalfDown:
+		return "HalfDown"
+	case Down:
+		return "Down"
+	case Up:
+		return "Up"
+	case Floor:
+		return "Floor"
+	case Ceiling:
+		return "Ceiling"
+	}
+	return "RoundingMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// roundsAway reports whether a result with a non-zero remainder moves one
+// unit away from zero under mode. half is the remainder compared to one
+// half (-1, 0, +1), neg whether the result is negative and odd whether its
+// truncated magnitude is odd.
+func roundsAway(mode RoundingMode, half int, neg, odd bool) bool {
+	switch mode {
+	case HalfUp:
+		return half >= 0
+	case HalfDown:
+		return half > 0
+	case Down:
+		return false
+	case Up:
+		return true
+	case Floor:
+		return neg
+	case Ceiling:
+		return !neg
+	}
+	return half > 0 || (half == 0 && odd) // HalfEven
+}
+
+// divRound returns n/d rounded with mode.
+func divRound(n, d *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	neg := n.Sign()*d.Sign() < 0
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(new(big.Int).Abs(d))
+	if roundsAway(mode, half, neg, q.Bit(0) == 1) {
+		if neg {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
@@ -0,0 +1,182 @@
+// Package apisign signs and verifies API request parameters the same way
+// generateSignature in advanced/apiSign.go does:
+//
+//  1. drop the sign parameter and sort the rest by name
+//  2. join them as name=value pairs with "&"
+//  3. HMAC-SHA256 the result with the client's secret key and hex-encode it
+//
+// For an HTTP request the HTTP method and path are signed too, on lines of
+// their own before the parameters, and the names and values are
+// percent-encoded in step 2; see CanonicalString.
+//
+// A request can pick another method with the sign_method parameter
+// (HMAC-SHA512 or Ed25519); sign_method is signed like every other
+// parameter, so it can't be downgraded on the way.
+package apisign
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Names of the parameters every signed request carries.
+const (
+	ParamAppID      = "app_id"      // identifies the client, and so its key
+	ParamTimestamp  = "timestamp"   // Unix seconds when the request was signed
+	ParamNonce      = "nonce"       // random value, never reused by a client
+	ParamSignMethod = "sign_method" // one of the Method* constants; MethodHMACSHA256 if absent
+	ParamBodyHash   = "body_sha256" // hex SHA-256 of the raw body, signed instead of the body members
+	ParamSign       = "sign"        // the signature itself
+)
+
+// Signature methods.
+const (
+	MethodHMACSHA256 = "hmac-sha256"
+	MethodHMACSHA512 = "hmac-sha512"
+	MethodEd25519    = "ed25519"
+)
+
+// A Signer signs canonical strings for a client.
+type Signer interface {
+	Method() string
+	Sign(canonical string) string // hex-encoded signature
+}
+
+// A Verifier checks signatures made by the matching Signer.
+type Verifier interface {
+	Method() string
+	Verify(canonical, sign string) bool
+}
+
+// NewHMAC returns a Signer and Verifier for an HMAC method (MethodHMACSHA256
+// or MethodHMACSHA512) with the shared secret key.
+func NewHMAC(method, secretKey string) (*HMAC, error) {
+	var h func() hash.Hash
+	switch method {
+	case MethodHMACSHA256:
+		h = sha256.New
+	case MethodHMACSHA512:
+		h = sha512.New
+	default:
+		return nil, fmt.Errorf("apisign: %q is not an HMAC method", method)
+	}
+	return &HMAC{method: method, hash: h, key: []byte(secretKey)}, nil
+}
+
+// HMAC signs with a secret key both sides share.
+type HMAC struct {
+	method string
+	hash   func() hash.Hash
+	key    []byte
+}
+
+func (s *HMAC) Method() string { return s.method }
+
+func (s *HMAC) Sign(canonical string) string {
+	h := hmac.New(s.hash, s.key)
+	h.Write([]byte(canonical))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify compares in constant time, so an attacker can't learn how many
+// leading characters of a forged signature were right.
+func (s *HMAC) Verify(canonical, sign string) bool {
+	return hmac.Equal([]byte(s.Sign(canonical)), []byte(strings.ToLower(sign)))
+}
+
+// Ed25519Signer signs with a client's private key; the server only needs
+// the public key, held by an Ed25519Verifier.
+type Ed25519Signer struct {
+	Key ed25519.PrivateKey
+}
+
+func (Ed25519Signer) Method() string { return MethodEd25519 }
+
+func (s Ed25519Signer) Sign(canonical string) string {
+	return hex.EncodeToString(ed25519.Sign(s.Key, []byte(canonical)))
+}
+
+// Ed25519Verifier checks signatures made by an Ed25519Signer.
+type Ed25519Verifier struct {
+	Key ed25519.PublicKey
+}
+
+func (Ed25519Verifier) Method() string { return MethodEd25519 }
+
+func (v Ed25519Verifier) Verify(canonical, sign string) bool {
+	sig, err := hex.DecodeString(sign)
+	return err == nil && ed25519.Verify(v.Key, []byte(canonical), sig)
+}
+
+// CanonicalString returns the string that is signed for a request: the
+// upper-case HTTP method, the escaped path (as url.URL.EscapedPath returns
+// it) and the parameters, on three lines. Signing the method and path keeps
+// a captured signature from being replayed against another route.
+//
+// The parameters are encoded as url.Values.Encode does, so a value holding
+// & or = can't pass for other parameters, and a missing sign_method is
+// signed as MethodHMACSHA256, the method the server verifies with.
+func CanonicalString(method, path string, params map[string]string) string {
+	v := make(url.Values, len(params)+1)
+	for k, val := range params {
+		if k != ParamSign {
+			v.Set(k, val)
+		}
+	}
+	if v.Get(ParamSignMethod) == "" {
+		v.Set(ParamSignMethod, MethodHMACSHA256)
+	}
+	return strings.ToUpper(method) + "\n" + path + "\n" + v.Encode()
+}
+
+// paramString returns every parameter except sign, sorted by name, joined
+// as k=v&k=v: the string generateSignature in advanced/apiSign.go signs.
+// Nothing is escaped, so it is only used by the legacy Sign and Verify.
+func paramString(params map[string]string) string {
+	// 1. 提取参数名并排序
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != ParamSign { // 排除 sign 参数本身
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	// 2. 拼接待签名字符串
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+params[k])
+	}
+	return strings.Join(parts, "&")
+}
+
+// Sign returns the hex HMAC-SHA256 signature of params alone under
+// secretKey, exactly what generateSignature in advanced/apiSign.go returns.
+// HTTP requests are signed over CanonicalString instead, which also covers
+// the method and path.
+func Sign(params map[string]string, secretKey string) string {
+	// 3. 使用 HMAC-SHA256 签名
+	s, _ := NewHMAC(MethodHMACSHA256, secretKey)
+	return s.Sign(paramString(params))
+}
+
+// Verify reports whether sign is the signature Sign returns for params
+// under secretKey, comparing in constant time.
+func Verify(params map[string]string, secretKey, sign string) bool {
+	v, _ := NewHMAC(MethodHMACSHA256, secretKey)
+	return v.Verify(paramString(params), sign)
+}
+
+// BodyHash returns the value of the body_sha256 parameter for body.
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+package apisign
+
+import "testing"
+
+// The params and key from mainAPI in advanced/apiSign.go, so the two
+// implementations are known to agree.
+var mainAPIParams = map[string]string{
+	"timestamp": "1712345678",
+	"nonce":     "abc123",
+	"action":    "getUser",
+	"userId":    "1001",
+}
+
+const mainAPISign = "38e792f2b737ef6a5a768a9968eb085911d41a3e6cf411e596b5d7d700798669"
+
+func TestSign(t *testing.T) {
+	if got, want := CanonicalString("get", "/users/1001", mainAPIParams), "GET\n/users/1001\naction=getUser&nonce=abc123&sign_method=hmac-sha256&timestamp=1712345678&userId=1001"; got != want {
+		t.Errorf("CanonicalString = %q, want %q", got, want)
+	}
+	escaped := map[string]string{"q": "a b&c=d", ParamSignMethod: MethodEd25519, ParamSign: "x"}
+	if got, want := CanonicalString("GET", "/albums", escaped), "GET\n/albums\nq=a+b%26c%3Dd&sign_method=ed25519"; got != want {
+		t.Errorf("CanonicalString = %q, want %q", got, want)
+	}
+	if got := Sign(mainAPIParams, "your-secret-key-here"); got != mainAPISign {
+		t.Errorf("Sign = %s, want %s", got, mainAPISign)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	params := map[string]string{ParamSign: mainAPISign}
+	for k, v := range mainAPIParams {
+		params[k] = v
+	}
+	if !Verify(params, "your-secret-key-here", mainAPISign) {
+		t.Error("Verify with the right key = false, want true")
+	}
+	if Verify(params, "wrong-key", mainAPISign) {
+		t.Error("Verify with the wrong key = true, want false")
+	}
+	params["userId"] = "1002"
+	if Verify(params, "your-secret-key-here", mainAPISign) {
+		t.Error("Verify after changing userId = true, want false")
+	}
+}
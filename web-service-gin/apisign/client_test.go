@@ -0,0 +1,84 @@
+package apisign
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verify checks req's signature the way the server does.
+func verify(t *testing.T, v Verifier, req *http.Request, body string) bool {
+	t.Helper()
+	params, err := RequestParams(req, []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.Verify(CanonicalString(req.Method, req.URL.EscapedPath(), params), params[ParamSign])
+}
+
+func TestClientSignsMethodPathAndBody(t *testing.T) {
+	h, _ := NewHMAC(MethodHMACSHA512, "s3cret")
+	c := NewClient("web", h)
+	c.Now = func() time.Time { return time.Unix(1712345678, 0) }
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/albums/1?x=y", nil)
+	if _, err := c.SignRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	if !verify(t, h, req, "") {
+		t.Fatal("signed GET /albums/1 doesn't verify")
+	}
+	other := req.Clone(req.Context())
+	other.Method = http.MethodDelete
+	if verify(t, h, other, "") {
+		t.Error("GET signature verifies as DELETE")
+	}
+	other = req.Clone(req.Context())
+	other.URL.Path = "/albums/2"
+	if verify(t, h, other, "") {
+		t.Error("signature for /albums/1 verifies for /albums/2")
+	}
+
+	// A body of any content type is covered by body_sha256.
+	body := `{"title":"Giant Steps"}`
+	req, _ = http.NewRequest(http.MethodPost, "http://example.com/albums", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain")
+	if _, err := c.SignRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := req.URL.Query().Get(ParamBodyHash), BodyHash([]byte(body)); got != want {
+		t.Errorf("body_sha256 = %q, want %q", got, want)
+	}
+	if !verify(t, h, req, body) {
+		t.Error("signed text/plain POST doesn't verify")
+	}
+}
+
+// A value holding & or = is escaped in the canonical string, so its
+// signature doesn't verify for the parameters it spells out.
+func TestClientEscapesValues(t *testing.T) {
+	h, _ := NewHMAC(MethodHMACSHA256, "s3cret")
+	c := NewClient("web", h)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/albums?artist=a%26genre%3Djazz", nil)
+	canonical, err := c.SignRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(canonical, "artist=a%26genre%3Djazz") {
+		t.Errorf("canonical = %q, want the artist escaped", canonical)
+	}
+	if !verify(t, h, req, "") {
+		t.Fatal("signed GET doesn't verify")
+	}
+
+	q := req.URL.Query()
+	q.Set("artist", "a")
+	q.Set("genre", "jazz")
+	other := req.Clone(req.Context())
+	other.URL.RawQuery = q.Encode()
+	if verify(t, h, other, "") {
+		t.Error(`signature for artist="a&genre=jazz" verifies for artist=a, genre=jazz`)
+	}
+}
@@ -0,0 +1,77 @@
+package apisign
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrUnsignedBody is returned by RequestParams for a body that is neither
+// form-encoded nor JSON and isn't covered by body_sha256.
+var ErrUnsignedBody = errors.New("apisign: body is not signed")
+
+// RequestParams returns the parameters that are signed for r, whose body has
+// already been read into body. Client and server both call it, so they can't
+// disagree about the canonical string.
+//
+// The parameters are the query parameters plus the body parameters: form
+// fields for a form-encoded body, or the top-level members of a JSON object
+// (strings without their quotes, anything else as written in the body).
+// When the query carries body_sha256 the body is covered by that hash and
+// its members are not added. Any other body must carry body_sha256: its
+// content isn't signed otherwise, so RequestParams returns ErrUnsignedBody.
+// A parameter given more than once has its values joined with ",".
+func RequestParams(r *http.Request, body []byte) (map[string]string, error) {
+	params := map[string]string{}
+	for k, vs := range r.URL.Query() {
+		params[k] = strings.Join(vs, ",")
+	}
+	if len(body) == 0 || params[ParamBodyHash] != "" {
+		return params, nil
+	}
+
+	bodyParams := map[string]string{}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case contentType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, vs := range form {
+			bodyParams[k] = strings.Join(vs, ",")
+		}
+	case isJSON(contentType):
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(body, &members); err != nil {
+			return nil, err
+		}
+		for k, raw := range members {
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				bodyParams[k] = s
+			} else {
+				bodyParams[k] = string(raw)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: a %q body needs %s", ErrUnsignedBody, contentType, ParamBodyHash)
+	}
+	for k, v := range bodyParams {
+		if _, ok := params[k]; ok {
+			return nil, fmt.Errorf("apisign: parameter %q is in both the query and the body", k)
+		}
+		params[k] = v
+	}
+	return params, nil
+}
+
+// isJSON reports whether contentType is application/json or a +json type
+// such as application/merge-patch+json.
+func isJSON(contentType string) bool {
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"example/web-service-gin/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// claimsKey is the gin.Context key requireJWT stores the verified jwt.Claims under.
+const claimsKey = "jwtClaims"
+
+// requireJWT returns a middleware that only lets through requests carrying
+// "Authorization: Bearer <token>" with a token that verifies against keys and
+// passes v. The token is put into the request context (see jwt.FromContext)
+// and its claims into the gin.Context under claimsKey.
+func requireJWT(keys jwt.KeyFunc, v jwt.Validator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || raw == "" {
+			c.Header("WWW-Authenticate", `Bearer`)
+			respondError(c, http.StatusUnauthorized, codeTokenMissing, "Authorization: Bearer token is required")
+			return
+		}
+
+		token, err := jwt.Parse(raw, keys, v)
+		if err != nil {
+			code := codeTokenInvalid
+			if errors.Is(err, jwt.ErrExpired) {
+				code = codeTokenExpired
+			}
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			respondError(c, http.StatusUnauthorized, code, err.Error())
+			return
+		}
+
+		c.Set(claimsKey, token.Claims)
+		c.Request = c.Request.WithContext(jwt.NewContext(c.Request.Context(), token))
+		c.Next()
+	}
+}
+
+// parseJWTKeys parses a list of verification keys separated by commas, each
+// kid:alg:value. value is the shared secret for HS256/384/512, or the path of
+// a PEM public key file for RS256 and ES256, e.g.
+// "2024:HS256:s3cret,2025:RS256:/etc/albums/jwt.pub".
+func parseJWTKeys(s string) (jwt.KeySet, error) {
+	keys := jwt.KeySet{}
+	for _, entry := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("jwt keys: %q is not kid:alg:value", entry)
+		}
+		kid, alg, value := parts[0], parts[1], parts[2]
+
+		switch alg {
+		case jwt.HS256, jwt.HS384, jwt.HS512:
+			keys[kid] = jwt.Key{Alg: alg, Key: []byte(value)}
+		case jwt.RS256, jwt.ES256:
+			data, err := os.ReadFile(value)
+			if err != nil {
+				return nil, fmt.Errorf("jwt keys: %s: %v", kid, err)
+			}
+			pub, err := jwt.ParsePublicKeyPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("jwt keys: %s: %v", kid, err)
+			}
+			keys[kid] = jwt.Key{Alg: alg, Key: pub}
+		default:
+			return nil, fmt.Errorf("jwt keys: %s: unsupported alg %q", kid, alg)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example/web-service-gin/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireJWT(t *testing.T) {
+	newTestRouter()
+	keys := jwt.KeySet{"2024": {Alg: jwt.HS256, Key: []byte("s3cret")}}
+	router := newRouter(requireJWT(keys.Lookup, jwt.Validator{Audience: "albums", RequireExpiry: true}))
+	router.GET("/whoami", func(c *gin.Context) {
+		token, _ := jwt.FromContext(c.Request.Context())
+		c.String(http.StatusOK, token.Claims.Subject)
+	})
+
+	sign := func(kid string, c jwt.Claims) string {
+		token, err := jwt.Sign(jwt.HS256, kid, []byte("s3cret"), c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := jwt.Claims{Subject: "1001", Audience: []string{"albums"}, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	expired := valid
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name       string
+		auth       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"valid", "Bearer " + sign("2024", valid), http.StatusOK, "1001"},
+		{"missing", "", http.StatusUnauthorized, codeTokenMissing},
+		{"expired", "Bearer " + sign("2024", expired), http.StatusUnauthorized, codeTokenExpired},
+		{"unknown kid", "Bearer " + sign("2023", valid), http.StatusUnauthorized, codeTokenInvalid},
+		{"garbage", "Bearer abc", http.StatusUnauthorized, codeTokenInvalid},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("%s: GET /whoami = %d %s, want %d %s", tt.name, w.Code, w.Body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+
+	"example.com/albums/albumio"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkBatchSize is how many albums postAlbumsBulk hands to AlbumStore.AddBatch at once.
+const bulkBatchSize = 500
+
+// codeInvalidRow is the apiError code of a bulk row that can't be decoded.
+const codeInvalidRow = "invalid_row"
+
+// rowError is a row of a bulk import that couldn't be added. Row counts the
+// albums in the body from 1, not counting a CSV header.
+type rowError struct {
+	Row int `json:"row"`
+	apiError
+}
+
+// bulkResult is the response body of POST /albums:bulk.
+type bulkResult struct {
+	Imported int        `json:"imported"`
+	Failed   int        `json:"failed"`
+	Errors   []rowError `json:"errors"`
+}
+
+// bulkFormat picks the format of a bulk body from the format query parameter
+// or else the Content-Type header.
+func bulkFormat(c *gin.Context) string {
+	if f := c.Query("format"); f != "" {
+		return f
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return albumio.FormatCSV
+	case "application/x-ndjson", "application/jsonl":
+		return albumio.FormatJSONL
+	}
+	return albumio.FormatJSON
+}
+
+// albumsAction serves POST /albums:<action>, a custom method in the style of
+// Google's API design guide. Gin 1.10 has no literal ":" in paths, so the
+// action is a path parameter that keeps its leading colon.
+func albumsAction(c *gin.Context) {
+	switch c.Param("action") {
+	case ":bulk":
+		postAlbumsBulk(c)
+	default:
+		respondError(c, http.StatusNotFound, codeNotFound, "unknown action "+c.Param("action"))
+	}
+}
+
+// postAlbumsBulk adds every album in the request body, which is read as a
+// stream, so its size is not limited by memory. The format comes from
+// ?format=csv|jsonl|json or the Content-Type (text/csv, application/x-ndjson,
+// application/json). Albums are added bulkBatchSize at a time.
+//
+// A row that is malformed or invalid is reported in the response and
+// skipped; the others are still added. The server assigns the IDs, as for
+// POST /albums. A body that can't be read further ends the import, with the
+// reason as the last error.
+func postAlbumsBulk(c *gin.Context) {
+	dec, err := albumio.NewDecoder(bulkFormat(c), c.Request.Body)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRow, err.Error())
+		return
+	}
+
+	var result bulkResult
+	fail := func(row int, err error) {
+		e := rowError{Row: row, apiError: apiError{Code: codeInternal, Message: err.Error()}}
+		var invalid *ValidationError
+		var bad *albumio.RowError
+		switch {
+		case errors.As(err, &invalid):
+			e.apiError = apiError{Code: codeValidationFailed, Message: invalid.Message, Fields: invalid.Fields}
+		case errors.Is(err, ErrDuplicateAlbum):
+			e.Code = codeConflict
+		case errors.As(err, &bad):
+			e.apiError = apiError{Code: codeInvalidRow, Message: bad.Err.Error()}
+		}
+		result.Errors = append(result.Errors, e)
+		result.Failed++
+	}
+
+	var batch []album
+	var rows []int // row number of each album in batch
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		for i, err := range store.AddBatch(batch) {
+			if err != nil {
+				fail(rows[i], err)
+			} else {
+				result.Imported++
+			}
+		}
+		batch, rows = batch[:0], rows[:0]
+	}
+
+	for row := 1; ; row++ {
+		in, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if errors.As(err, new(*albumio.RowError)) {
+				fail(row, priceError(err))
+				continue
+			}
+			fail(row, &albumio.RowError{Row: row, Err: err})
+			break
+		}
+		a := album{ID: in.ID, Title: in.Title, Artist: in.Artist, Price: in.Price}
+		if a.ID != "" {
+			fail(row, invalidAlbum(fieldError{Field: "id", Message: "is assigned by the server"}))
+			continue
+		}
+		a.ID = ids.NewID()
+		if err := a.validate(); err != nil {
+			fail(row, err)
+			continue
+		}
+		batch = append(batch, a)
+		rows = append(rows, row)
+		if len(batch) == bulkBatchSize {
+			flush()
+		}
+	}
+	flush()
+	// Rows rejected by the store come after later rows that failed to decode.
+	slices.SortStableFunc(result.Errors, func(a, b rowError) int { return a.Row - b.Row })
+	if result.Errors == nil {
+		result.Errors = []rowError{} // [] rather than null
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
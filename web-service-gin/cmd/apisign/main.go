@@ -0,0 +1,111 @@
+// Command apisign signs album API requests the way apisign.Client does and
+// prints every step, to debug requests the server rejects as
+// signature_invalid.
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"example/web-service-gin/apisign"
+)
+
+/**
+Run:
+go run ./cmd/apisign sign -app web -key s3cret 'http://localhost:8080/albums?artist=John%20Coltrane'
+go run ./cmd/apisign sign -app web -key s3cret -X POST -d '{"title":"Giant Steps","artist":"John Coltrane","price":63.99}' http://localhost:8080/albums
+go run ./cmd/apisign keygen       // new Ed25519 key pair for -method ed25519
+*/
+
+func main() {
+	log.SetPrefix("apisign: ")
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "sign":
+		sign(os.Args[2:])
+	case "keygen":
+		keygen()
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: apisign sign [flags] URL")
+	fmt.Fprintln(os.Stderr, "       apisign keygen")
+	os.Exit(2)
+}
+
+// sign prints the canonical string, signature and signed URL of a request.
+func sign(args []string) {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	appID := fs.String("app", "", "app_id of the client")
+	key := fs.String("key", "", "shared secret, or hex Ed25519 private key for -method ed25519")
+	method := fs.String("method", apisign.MethodHMACSHA256, "hmac-sha256, hmac-sha512 or ed25519")
+	httpMethod := fs.String("X", http.MethodGet, "HTTP method")
+	body := fs.String("d", "", "request body")
+	contentType := fs.String("H", "application/json", "Content-Type of the body")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *appID == "" || *key == "" {
+		usage()
+	}
+
+	signer, err := newSigner(*method, *key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req, err := http.NewRequest(*httpMethod, fs.Arg(0), strings.NewReader(*body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *body != "" {
+		req.Header.Set("Content-Type", *contentType)
+	}
+
+	canonical, err := apisign.NewClient(*appID, signer).SignRequest(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("canonical: %q\n", canonical)
+	fmt.Printf("sign:      %s\n", req.URL.Query().Get(apisign.ParamSign))
+	fmt.Printf("url:       %s\n", req.URL)
+}
+
+func newSigner(method, key string) (apisign.Signer, error) {
+	if method != apisign.MethodEd25519 {
+		return apisign.NewHMAC(method, key)
+	}
+	b, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("ed25519 key: %v", err)
+	}
+	switch len(b) {
+	case ed25519.SeedSize:
+		return apisign.Ed25519Signer{Key: ed25519.NewKeyFromSeed(b)}, nil
+	case ed25519.PrivateKeySize:
+		return apisign.Ed25519Signer{Key: b}, nil
+	}
+	return nil, fmt.Errorf("ed25519 key: want %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(b))
+}
+
+// keygen prints a new Ed25519 key pair: the private key for the client and
+// the public key for the server's -sign-keys.
+func keygen() {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("private key: %x\n", priv.Seed())
+	fmt.Printf("public key:  %x\n", pub)
+	fmt.Printf("server flag: -sign-keys=APP_ID:ed25519:%x\n", pub)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"example.com/albums/albumdb"
+)
+
+// config holds the settings of the album service. Each one can be set with a
+// flag or, if the flag is not given, with the ALBUMS_* environment variable
+// shown in its usage text.
+type config struct {
+	addr            string
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	shutdownTimeout time.Duration
+	tlsCert         string // serve HTTPS when both tlsCert and tlsKey are set
+	tlsKey          string
+	signKeys        string        // app_id:secret pairs; requests must be signed when set
+	signSkew        time.Duration // max distance of a signed timestamp from server time
+	jwtKeys         string        // kid:alg:value list; a bearer token is required when set
+	jwtIssuer       string
+	jwtAudience     string
+	jwtLeeway       time.Duration
+
+	store      string
+	dataPath   string
+	idStrategy string
+
+	// Database of -store=sql and the sizing of its connection pool.
+	dbDriver        string
+	dbDSN           string
+	dbPool          albumdb.Pool
+	dbProbeInterval time.Duration // between health pings
+	dbMigrate       bool          // apply pending migrations at startup instead of refusing to start
+}
+
+// loadConfig parses args (without the program name). Environment variables
+// are looked up with getenv, so tests don't have to touch the real environment.
+func loadConfig(args []string, getenv func(string) string) (config, error) {
+	var cfg config
+	fs := flag.NewFlagSet("web-service-gin", flag.ContinueOnError)
+
+	env := func(name, def string) string {
+		if v := getenv(name); v != "" {
+			return v
+		}
+		return def
+	}
+	envInt := func(name string, def int) (int, error) {
+		v := getenv(name)
+		if v == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", name, err)
+		}
+		return n, nil
+	}
+	envDuration := func(name string, def time.Duration) (time.Duration, error) {
+		v := getenv(name)
+		if v == "" {
+			return def, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", name, err)
+		}
+		return d, nil
+	}
+
+	fs.StringVar(&cfg.addr, "addr", env("ALBUMS_ADDR", "localhost:8080"), "listen address (ALBUMS_ADDR)")
+	fs.StringVar(&cfg.tlsCert, "tls-cert", env("ALBUMS_TLS_CERT", ""), "TLS certificate file (ALBUMS_TLS_CERT)")
+	fs.StringVar(&cfg.tlsKey, "tls-key", env("ALBUMS_TLS_KEY", ""), "TLS key file (ALBUMS_TLS_KEY)")
+	fs.StringVar(&cfg.signKeys, "sign-keys", env("ALBUMS_SIGN_KEYS", ""), "app_id:secret pairs, comma separated; enables request signatures (ALBUMS_SIGN_KEYS)")
+	fs.StringVar(&cfg.jwtKeys, "jwt-keys", env("ALBUMS_JWT_KEYS", ""), "kid:alg:secret-or-pem-file list, comma separated; requires a JWT bearer token (ALBUMS_JWT_KEYS)")
+	fs.StringVar(&cfg.jwtIssuer, "jwt-issuer", env("ALBUMS_JWT_ISSUER", ""), "required iss of JWTs (ALBUMS_JWT_ISSUER)")
+	fs.StringVar(&cfg.jwtAudience, "jwt-audience", env("ALBUMS_JWT_AUDIENCE", ""), "required aud of JWTs (ALBUMS_JWT_AUDIENCE)")
+	fs.StringVar(&cfg.store, "store", env("ALBUMS_STORE", "memory"), "album store: memory, file or sql (ALBUMS_STORE)")
+	fs.StringVar(&cfg.dataPath, "data", env("ALBUMS_DATA", "albums.json"), "JSON file used by -store=file (ALBUMS_DATA)")
+	fs.StringVar(&cfg.idStrategy, "ids", env("ALBUMS_IDS", "monotonic"), "album id strategy: monotonic or uuid (ALBUMS_IDS)")
+	fs.StringVar(&cfg.dbDriver, "db-driver", env("ALBUMS_DB_DRIVER", "sqlite3"), "database driver of -store=sql: sqlite3 or mysql (ALBUMS_DB_DRIVER)")
+	fs.StringVar(&cfg.dbDSN, "db-dsn", env("ALBUMS_DB_DSN", "file:albums.db?_busy_timeout=5000"), "data source name of -store=sql; for mysql add clientFoundRows=true (ALBUMS_DB_DSN)")
+
+	migrate, err := strconv.ParseBool(env("ALBUMS_DB_MIGRATE", "false"))
+	if err != nil {
+		return cfg, fmt.Errorf("ALBUMS_DB_MIGRATE: %v", err)
+	}
+	fs.BoolVar(&cfg.dbMigrate, "db-migrate", migrate, "apply pending migrations of -store=sql at startup; without it the service won't start on an out-of-date database (ALBUMS_DB_MIGRATE)")
+
+	for _, n := range []struct {
+		dst  *int
+		flag string
+		env  string
+		def  int
+		doc  string
+	}{
+		{&cfg.dbPool.MaxOpen, "db-max-open", "ALBUMS_DB_MAX_OPEN", albumdb.DefaultPool.MaxOpen, "max open database connections, 0 for no limit"},
+		{&cfg.dbPool.MaxIdle, "db-max-idle", "ALBUMS_DB_MAX_IDLE", albumdb.DefaultPool.MaxIdle, "max idle database connections kept in the pool, at most -db-max-open"},
+	} {
+		def, err := envInt(n.env, n.def)
+		if err != nil {
+			return cfg, err
+		}
+		fs.IntVar(n.dst, n.flag, def, n.doc+" ("+n.env+")")
+	}
+
+	for _, d := range []struct {
+		dst  *time.Duration
+		flag string
+		env  string
+		def  time.Duration
+		doc  string
+	}{
+		{&cfg.readTimeout, "read-timeout", "ALBUMS_READ_TIMEOUT", 10 * time.Second, "max time to read a request"},
+		{&cfg.writeTimeout, "write-timeout", "ALBUMS_WRITE_TIMEOUT", 10 * time.Second, "max time to write a response"},
+		{&cfg.idleTimeout, "idle-timeout", "ALBUMS_IDLE_TIMEOUT", 60 * time.Second, "max time a keep-alive connection stays idle"},
+		{&cfg.signSkew, "sign-skew", "ALBUMS_SIGN_SKEW", 5 * time.Minute, "max clock skew of a signed request's timestamp"},
+		{&cfg.jwtLeeway, "jwt-leeway", "ALBUMS_JWT_LEEWAY", 30 * time.Second, "clock skew tolerated on JWT exp, nbf and iat"},
+		{&cfg.shutdownTimeout, "shutdown-timeout", "ALBUMS_SHUTDOWN_TIMEOUT", 15 * time.Second, "max time to drain requests on shutdown"},
+		{&cfg.dbPool.MaxLifetime, "db-conn-max-lifetime", "ALBUMS_DB_CONN_MAX_LIFETIME", albumdb.DefaultPool.MaxLifetime, "max time a database connection is reused, 0 for no limit"},
+		{&cfg.dbProbeInterval, "db-probe-interval", "ALBUMS_DB_PROBE_INTERVAL", 10 * time.Second, "time between database health pings"},
+	} {
+		def, err := envDuration(d.env, d.def)
+		if err != nil {
+			return cfg, err
+		}
+		fs.DurationVar(d.dst, d.flag, def, d.doc+" ("+d.env+")")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if (cfg.tlsCert == "") != (cfg.tlsKey == "") {
+		return cfg, fmt.Errorf("-tls-cert and -tls-key must be set together")
+	}
+	if cfg.store == "sql" && cfg.idStrategy != "monotonic" {
+		// The album table's ids are integers.
+		return cfg, fmt.Errorf("-store=sql needs -ids=monotonic")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLoadConfig checks that env vars set defaults and flags override them.
+func TestLoadConfig(t *testing.T) {
+	env := map[string]string{
+		"ALBUMS_ADDR":         ":9090",
+		"ALBUMS_READ_TIMEOUT": "3s",
+		"ALBUMS_STORE":        "file",
+		"ALBUMS_DB_MIGRATE":   "true",
+	}
+	cfg, err := loadConfig([]string{"-store=memory", "-idle-timeout=2m"}, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.addr != ":9090" || cfg.readTimeout != 3*time.Second || cfg.store != "memory" || cfg.idleTimeout != 2*time.Minute {
+		t.Errorf("loadConfig = %+v, want addr :9090, read 3s, store memory, idle 2m", cfg)
+	}
+	if !cfg.dbMigrate {
+		t.Error("dbMigrate = false, want true from ALBUMS_DB_MIGRATE")
+	}
+	if cfg.writeTimeout != 10*time.Second {
+		t.Errorf("writeTimeout = %v, want default 10s", cfg.writeTimeout)
+	}
+
+	noEnv := func(string) string { return "" }
+	if _, err := loadConfig([]string{"-tls-cert=cert.pem"}, noEnv); err == nil {
+		t.Error("loadConfig with -tls-cert but no -tls-key: want error")
+	}
+	if _, err := loadConfig([]string{"-store=sql", "-ids=uuid"}, noEnv); err == nil {
+		t.Error("loadConfig with -store=sql and -ids=uuid: want error")
+	}
+	if _, err := loadConfig(nil, func(k string) string { return map[string]string{"ALBUMS_IDLE_TIMEOUT": "soon"}[k] }); err == nil {
+		t.Error("loadConfig with ALBUMS_IDLE_TIMEOUT=soon: want error")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"example.com/albums/albumdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openDB opens the database of -store=sql and sizes its connection pool.
+// It doesn't connect; the first query or the albumdb.Prober's ping does.
+func openDB(cfg config) (*sql.DB, albumdb.Dialect, error) {
+	return albumdb.Open(cfg.dbDriver, cfg.dbDSN, cfg.dbPool)
+}
+
+// debugDB responds with the health of the database and the statistics of
+// its connection pool. The status is 503 while the last ping failed.
+//
+// The ping's error isn't in the response, since the route has no
+// authentication and a driver error can name hosts and users; the prober
+// logs it.
+func debugDB(db *sql.DB, p *albumdb.Prober) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := db.Stats()
+		ps := p.Status()
+		body := gin.H{
+			"healthy":              ps.Healthy,
+			"last_ping":            ps.LastPing,
+			"consecutive_failures": ps.Failures,
+			"pool": gin.H{
+				"max_open_connections": s.MaxOpenConnections,
+				"open_connections":     s.OpenConnections,
+				"in_use":               s.InUse,
+				"idle":                 s.Idle,
+				"wait_count":           s.WaitCount,
+				"wait_duration_ms":     s.WaitDuration.Milliseconds(),
+				"max_idle_closed":      s.MaxIdleClosed,
+				"max_idle_time_closed": s.MaxIdleTimeClosed,
+				"max_lifetime_closed":  s.MaxLifetimeClosed,
+			},
+		}
+		status := http.StatusOK
+		if !ps.Healthy {
+			status = http.StatusServiceUnavailable
+		}
+		c.IndentedJSON(status, body)
+	}
+}
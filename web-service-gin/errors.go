@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable codes sent in apiError.Code.
+const (
+	codeInvalidJSON          = "invalid_json"
+	codeValidationFailed     = "validation_failed"
+	codeNotFound             = "not_found"
+	codeConflict             = "conflict"
+	codeIdempotencyKeyReused = "idempotency_key_reused"
+	codeSignatureMissing     = "signature_missing"
+	codeSignatureInvalid     = "signature_invalid"
+	codeUnknownClient        = "unknown_client"
+	codeTimestampOutOfRange  = "timestamp_out_of_range"
+	codeNonceReplayed        = "nonce_replayed"
+	codeTokenMissing         = "token_missing"
+	codeTokenInvalid         = "token_invalid"
+	codeTokenExpired         = "token_expired"
+	codeInternal             = "internal_error"
+)
+
+// apiError is the JSON body of every error response, e.g.
+//
+//	{"code": "validation_failed", "message": "invalid album",
+//	 "fields": [{"field": "title", "message": "is required"}]}
+type apiError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []fieldError `json:"fields,omitempty"`
+}
+
+// fieldError describes why a single request field was rejected.
+type fieldError struct {
+	Field   string `json:"field"` // JSON name of the field
+	Message string `json:"message"`
+}
+
+// respondError aborts the request with status and an apiError body.
+func respondError(c *gin.Context, status int, code, message string, fields ...fieldError) {
+	c.AbortWithStatusJSON(status, apiError{Code: code, Message: message, Fields: fields})
+}
+
+// respondDomainError maps an error from the album rules or the AlbumStore
+// to a response. It is the one place that decides their status codes:
+//
+//	*ValidationError   400 validation_failed
+//	ErrAlbumNotFound   404 not_found
+//	ErrDuplicateAlbum  409 conflict
+//	anything else      500 internal_error
+func respondDomainError(c *gin.Context, err error) {
+	var invalid *ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		respondError(c, http.StatusBadRequest, codeValidationFailed, invalid.Message, invalid.Fields...)
+	case errors.Is(err, ErrAlbumNotFound):
+		respondError(c, http.StatusNotFound, codeNotFound, "album not found")
+	case errors.Is(err, ErrDuplicateAlbum):
+		respondError(c, http.StatusConflict, codeConflict, "album already exists",
+			fieldError{Field: "id", Message: "is already used by another album"})
+	default:
+		respondError(c, http.StatusInternalServerError, codeInternal, err.Error())
+	}
+}
+
+// respondBindError responds to an album body that couldn't be decoded. A
+// price with too many decimals decodes to a *ValidationError; anything
+// else is malformed JSON.
+func respondBindError(c *gin.Context, err error) {
+	if errors.As(err, new(*ValidationError)) {
+		respondDomainError(c, err)
+		return
+	}
+	respondError(c, http.StatusBadRequest, codeInvalidJSON, err.Error())
+}
+
+// errIDMismatch rejects a body whose id differs from the id in the URL.
+var errIDMismatch = invalidAlbum(fieldError{Field: "id", Message: "does not match the URL"})
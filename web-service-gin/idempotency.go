@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyCache remembers the response sent for each Idempotency-Key, so a
+// client that retries a request (e.g. after a timeout) gets the original
+// response back instead of creating a second album.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idempotentResponse
+	expiry  []expiringKey // recorded responses, oldest first
+}
+
+// idempotentResponse is the recorded response for one Idempotency-Key. It is
+// only read or written with the cache's mu held.
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte // method, path and body of the original request
+	inFlight    bool              // the original request is still being handled
+	expires     time.Time         // set once the response is recorded
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// expiringKey is an entry of idempotencyCache.expiry.
+type expiringKey struct {
+	key string
+	r   *idempotentResponse
+}
+
+// newIdempotencyCache returns a cache that forgets keys ttl after their
+// response was recorded.
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{ttl: ttl, entries: make(map[string]*idempotentResponse)}
+}
+
+// idempotent returns a middleware that replays the recorded response when a
+// request repeats an Idempotency-Key. Reusing a key with a different request
+// is rejected with 422, and a retry that arrives while the original is still
+// running gets 409. 5xx responses are not recorded, so they can be retried.
+func idempotent(cache *idempotencyCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			respondError(c, http.StatusBadRequest, codeInvalidJSON, err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body)) // let the handler read it again
+		fingerprint := sha256.Sum256([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n" + string(body)))
+
+		cache.mu.Lock()
+		cache.expire(time.Now())
+		if r, ok := cache.entries[key]; ok {
+			// Copy the entry while holding the lock: the original request
+			// may still be filling it in.
+			recorded := *r
+			cache.mu.Unlock()
+			switch {
+			case recorded.fingerprint != fingerprint:
+				respondError(c, http.StatusUnprocessableEntity, codeIdempotencyKeyReused,
+					"Idempotency-Key was already used for a different request")
+			case recorded.inFlight:
+				respondError(c, http.StatusConflict, codeConflict,
+					"a request with this Idempotency-Key is still in progress")
+			default:
+				recorded.replay(c)
+			}
+			return
+		}
+		r := &idempotentResponse{fingerprint: fingerprint, inFlight: true}
+		cache.entries[key] = r
+		cache.mu.Unlock()
+
+		// A handler that panics never gets to record its response; forget
+		// the key then, as for a 5xx, or every retry would get 409.
+		finished := false
+		defer func() {
+			if !finished {
+				cache.mu.Lock()
+				delete(cache.entries, key)
+				cache.mu.Unlock()
+			}
+		}()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		finished = true
+
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		if w.Status() >= http.StatusInternalServerError {
+			delete(cache.entries, key)
+			return
+		}
+		r.inFlight = false
+		r.expires = time.Now().Add(cache.ttl)
+		r.status = w.Status()
+		r.header = w.Header().Clone()
+		r.body = bytes.Clone(w.body.Bytes())
+		cache.expiry = append(cache.expiry, expiringKey{key, r})
+	}
+}
+
+// expire drops the recorded responses whose ttl has passed. Responses are
+// queued in the order they were recorded, so it only looks at the ones it
+// drops and the first one it keeps. The caller must hold c.mu.
+func (c *idempotencyCache) expire(now time.Time) {
+	n := 0
+	for _, e := range c.expiry {
+		if now.Before(e.r.expires) {
+			break
+		}
+		if c.entries[e.key] == e.r {
+			delete(c.entries, e.key)
+		}
+		n++
+	}
+	c.expiry = c.expiry[n:]
+}
+
+// replay writes the recorded response to c.
+func (r *idempotentResponse) replay(c *gin.Context) {
+	for k, v := range r.header {
+		c.Writer.Header()[k] = slices.Clone(v)
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Status(r.status)
+	c.Writer.Write(r.body)
+	c.Abort()
+}
+
+// recordingWriter keeps a copy of everything written to the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
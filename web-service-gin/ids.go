@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// idGenerator hands out IDs for new albums.
+type idGenerator interface {
+	NewID() string
+}
+
+// newIDGenerator returns the idGenerator selected by strategy.
+// existing is used by the monotonic strategy to continue after the largest ID in use.
+func newIDGenerator(strategy string, existing []album) (idGenerator, error) {
+	switch strategy {
+	case "monotonic":
+		return newMonotonicIDs(existing), nil
+	case "uuid":
+		return uuidIDs{}, nil
+	default:
+		return nil, fmt.Errorf("unknown id strategy %s, want monotonic or uuid", strategy)
+	}
+}
+
+// monotonicIDs generates "1", "2", "3", ... like an AUTO_INCREMENT column.
+type monotonicIDs struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+// newMonotonicIDs starts counting after the largest numeric ID in albums.
+// Non-numeric IDs are ignored.
+func newMonotonicIDs(albums []album) *monotonicIDs {
+	g := &monotonicIDs{}
+	for _, a := range albums {
+		if n, err := strconv.ParseUint(a.ID, 10, 64); err == nil && n > g.last {
+			g.last = n
+		}
+	}
+	return g
+}
+
+func (g *monotonicIDs) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.last++
+	return strconv.FormatUint(g.last, 10)
+}
+
+// uuidIDs generates random (version 4) UUIDs.
+type uuidIDs struct{}
+
+func (uuidIDs) NewID() string {
+	var b [16]byte
+	rand.Read(b[:])             // crypto/rand.Read never returns an error
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10 (RFC 4122)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
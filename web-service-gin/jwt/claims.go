@@ -0,0 +1,137 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Claims is the payload of a token. The registered claims have fields of
+// their own; any other ("private") claims are kept in Private.
+type Claims struct {
+	Issuer    string   // iss
+	Subject   string   // sub
+	Audience  []string // aud, a string or an array in JSON
+	ExpiresAt int64    // exp, Unix seconds; 0 means not set
+	NotBefore int64    // nbf, Unix seconds; 0 means not set
+	IssuedAt  int64    // iat, Unix seconds; 0 means not set
+	ID        string   // jti
+	Private   map[string]any
+}
+
+// registered is the JSON form of the registered claims.
+type registered struct {
+	Issuer    string          `json:"iss,omitempty"`
+	Subject   string          `json:"sub,omitempty"`
+	Audience  json.RawMessage `json:"aud,omitempty"`
+	ExpiresAt int64           `json:"exp,omitempty"`
+	NotBefore int64           `json:"nbf,omitempty"`
+	IssuedAt  int64           `json:"iat,omitempty"`
+	ID        string          `json:"jti,omitempty"`
+}
+
+var registeredNames = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+func (c Claims) MarshalJSON() ([]byte, error) {
+	r := registered{
+		Issuer: c.Issuer, Subject: c.Subject, ID: c.ID,
+		ExpiresAt: c.ExpiresAt, NotBefore: c.NotBefore, IssuedAt: c.IssuedAt,
+	}
+	// A single audience is written as a plain string, the common form.
+	switch len(c.Audience) {
+	case 0:
+	case 1:
+		r.Audience, _ = json.Marshal(c.Audience[0])
+	default:
+		r.Audience, _ = json.Marshal(c.Audience)
+	}
+	b, err := json.Marshal(r)
+	if err != nil || len(c.Private) == 0 {
+		return b, err
+	}
+
+	all := map[string]any{}
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	for k, v := range c.Private {
+		if slices.Contains(registeredNames, k) {
+			return nil, fmt.Errorf("jwt: private claim %q clashes with a registered claim", k)
+		}
+		all[k] = v
+	}
+	return json.Marshal(all)
+}
+
+func (c *Claims) UnmarshalJSON(b []byte) error {
+	var r registered
+	if err := json.Unmarshal(b, &r); err != nil {
+		return err
+	}
+	*c = Claims{
+		Issuer: r.Issuer, Subject: r.Subject, ID: r.ID,
+		ExpiresAt: r.ExpiresAt, NotBefore: r.NotBefore, IssuedAt: r.IssuedAt,
+	}
+	if len(r.Audience) > 0 {
+		var one string
+		if err := json.Unmarshal(r.Audience, &one); err == nil {
+			c.Audience = []string{one}
+		} else if err := json.Unmarshal(r.Audience, &c.Audience); err != nil {
+			return fmt.Errorf("aud: %v", err)
+		}
+	}
+
+	var all map[string]any
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber() // keep large integer claims such as user IDs exact
+	if err := d.Decode(&all); err != nil {
+		return err
+	}
+	for _, k := range registeredNames {
+		delete(all, k)
+	}
+	if len(all) > 0 {
+		c.Private = all
+	}
+	return nil
+}
+
+// Validator checks the registered claims of a token.
+type Validator struct {
+	Issuer        string           // if set, iss must equal it
+	Audience      string           // if set, aud must contain it
+	Leeway        time.Duration    // clock skew tolerated on exp, nbf and iat
+	RequireExpiry bool             // reject tokens without exp
+	Now           func() time.Time // nil means time.Now
+}
+
+// Validate returns nil if c passes every check of v, or an error wrapping
+// ErrExpired, ErrNotYetValid, ErrIssuedInFuture, ErrIssuer, ErrAudience or
+// ErrMissingClaim.
+func (v Validator) Validate(c Claims) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	// Expressed in Unix seconds, like the claims.
+	late := now.Add(-v.Leeway).Unix()
+	early := now.Add(v.Leeway).Unix()
+
+	switch {
+	case c.ExpiresAt == 0 && v.RequireExpiry:
+		return fmt.Errorf("%w: exp", ErrMissingClaim)
+	case c.ExpiresAt != 0 && late >= c.ExpiresAt:
+		return fmt.Errorf("%w: exp %s", ErrExpired, time.Unix(c.ExpiresAt, 0).UTC().Format(time.RFC3339))
+	case c.NotBefore != 0 && early < c.NotBefore:
+		return fmt.Errorf("%w: nbf %s", ErrNotYetValid, time.Unix(c.NotBefore, 0).UTC().Format(time.RFC3339))
+	case c.IssuedAt != 0 && early < c.IssuedAt:
+		return fmt.Errorf("%w: iat %s", ErrIssuedInFuture, time.Unix(c.IssuedAt, 0).UTC().Format(time.RFC3339))
+	case v.Issuer != "" && c.Issuer != v.Issuer:
+		return fmt.Errorf("%w: %q", ErrIssuer, c.Issuer)
+	case v.Audience != "" && !slices.Contains(c.Audience, v.Audience):
+		return fmt.Errorf("%w: %q", ErrAudience, c.Audience)
+	}
+	return nil
+}
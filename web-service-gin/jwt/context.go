@@ -0,0 +1,16 @@
+package jwt
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries the verified token t.
+func NewContext(ctx context.Context, t *Token) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the token stored in ctx by NewContext, if any.
+func FromContext(ctx context.Context) (*Token, bool) {
+	t, ok := ctx.Value(contextKey{}).(*Token)
+	return t, ok
+}
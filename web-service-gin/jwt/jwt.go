@@ -0,0 +1,126 @@
+// Package jwt issues and verifies JSON Web Tokens (RFC 7519) signed with
+// HS256, HS384, HS512, RS256 or ES256, using only the standard library.
+//
+// A token is three base64url parts joined by dots, Header.Payload.Signature,
+// where the signature covers "Header.Payload". See 标准库/算法/jwt.md.
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by Parse. They are wrapped, so test them with errors.Is.
+var (
+	ErrMalformed      = errors.New("jwt: malformed token")
+	ErrAlgorithm      = errors.New("jwt: unexpected signing algorithm")
+	ErrUnknownKey     = errors.New("jwt: unknown key id")
+	ErrSignature      = errors.New("jwt: signature is invalid")
+	ErrExpired        = errors.New("jwt: token is expired")
+	ErrNotYetValid    = errors.New("jwt: token is not valid yet")
+	ErrIssuedInFuture = errors.New("jwt: token is issued in the future")
+	ErrIssuer         = errors.New("jwt: unexpected issuer")
+	ErrAudience       = errors.New("jwt: unexpected audience")
+	ErrMissingClaim   = errors.New("jwt: required claim is missing")
+)
+
+// Header is the JOSE header of a token.
+type Header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"` // which key signed the token, for key rotation
+}
+
+// Token is a parsed token whose signature and claims have been verified.
+type Token struct {
+	Header Header
+	Claims Claims
+}
+
+// Sign returns a token for claims signed by key with alg. kid names the key
+// so a verifier holding several keys can pick the right one; it may be empty.
+//
+// key is a []byte secret for HS256/384/512, an *rsa.PrivateKey for RS256
+// and an *ecdsa.PrivateKey on P-256 for ES256.
+func Sign(alg, kid string, key any, claims Claims) (string, error) {
+	m, err := method(alg)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(Header{Alg: alg, Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	// 1. 编码 Header 和 Payload 为 Base64Url  2. 用 "." 拼接
+	signingInput := encode(header) + "." + encode(payload)
+	// 3. 对拼接结果签名
+	sig, err := m.sign([]byte(signingInput), key)
+	if err != nil {
+		return "", fmt.Errorf("jwt: sign %s: %v", alg, err)
+	}
+	// 4. 签名结果 Base64Url 编码，作为第三段
+	return signingInput + "." + encode(sig), nil
+}
+
+// Parse verifies token and returns its header and claims.
+//
+// The verification key comes from keys, looked up by the token's kid. The
+// token's alg must be the one registered for that key, so a token can't
+// make the verifier treat an RSA public key as an HMAC secret, and "none"
+// is never accepted. The claims are then checked with v.
+func Parse(token string, keys KeyFunc, v Validator) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: want 3 parts, got %d", ErrMalformed, len(parts))
+	}
+
+	var t Token
+	if err := decodeJSON(parts[0], &t.Header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+	key, err := keys(t.Header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if t.Header.Alg != key.Alg {
+		return nil, fmt.Errorf("%w: token uses %q, key %q is for %q", ErrAlgorithm, t.Header.Alg, t.Header.Kid, key.Alg)
+	}
+	m, err := method(key.Alg)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrMalformed, err)
+	}
+	if !m.verify([]byte(parts[0]+"."+parts[1]), sig, key.Key) {
+		return nil, ErrSignature
+	}
+
+	if err := decodeJSON(parts[1], &t.Claims); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrMalformed, err)
+	}
+	if err := v.Validate(t.Claims); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeJSON(part string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,113 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignParse(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret := []byte("s3cret")
+
+	tests := []struct {
+		alg     string
+		signKey any
+		keys    KeySet
+	}{
+		{HS256, secret, KeySet{"k": {HS256, secret}}},
+		{HS384, secret, KeySet{"k": {HS384, secret}}},
+		{HS512, secret, KeySet{"k": {HS512, secret}}},
+		{RS256, rsaKey, KeySet{"k": {RS256, &rsaKey.PublicKey}}},
+		{ES256, ecKey, KeySet{"k": {ES256, &ecKey.PublicKey}}},
+	}
+	claims := Claims{
+		Subject:   "1001",
+		Audience:  []string{"albums"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		Private:   map[string]any{"role": "admin"},
+	}
+	for _, tt := range tests {
+		token, err := Sign(tt.alg, "k", tt.signKey, claims)
+		if err != nil {
+			t.Errorf("Sign(%s): %v", tt.alg, err)
+			continue
+		}
+		got, err := Parse(token, tt.keys.Lookup, Validator{Audience: "albums"})
+		if err != nil {
+			t.Errorf("Parse(%s): %v", tt.alg, err)
+			continue
+		}
+		if got.Header.Alg != tt.alg || got.Claims.Subject != "1001" || got.Claims.Private["role"] != "admin" {
+			t.Errorf("Parse(%s) = %+v, want sub 1001 role admin", tt.alg, got)
+		}
+
+		// Flip one character of the signature.
+		last := token[len(token)-2]
+		flipped := byte('A')
+		if last == 'A' {
+			flipped = 'B'
+		}
+		tampered := token[:len(token)-2] + string(flipped) + token[len(token)-1:]
+		if _, err := Parse(tampered, tt.keys.Lookup, Validator{}); !errors.Is(err, ErrSignature) && !errors.Is(err, ErrMalformed) {
+			t.Errorf("Parse(tampered %s) error = %v, want ErrSignature", tt.alg, err)
+		}
+	}
+}
+
+// TestAlgorithmConfusion checks that an HS256 token signed with an RSA public
+// key, or an unsigned token, is not accepted by an RS256 key.
+func TestAlgorithmConfusion(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys := KeySet{"k": {RS256, &rsaKey.PublicKey}}
+
+	token, _ := Sign(HS256, "k", []byte("public key bytes"), Claims{Subject: "x"})
+	if _, err := Parse(token, keys.Lookup, Validator{}); !errors.Is(err, ErrAlgorithm) {
+		t.Errorf("HS256 token for RS256 key: error = %v, want ErrAlgorithm", err)
+	}
+	none := encode([]byte(`{"alg":"none","kid":"k"}`)) + "." + encode([]byte(`{"sub":"x"}`)) + "."
+	if _, err := Parse(none, keys.Lookup, Validator{}); !errors.Is(err, ErrAlgorithm) {
+		t.Errorf("alg none: error = %v, want ErrAlgorithm", err)
+	}
+	if _, err := Parse(token, KeySet{}.Lookup, Validator{}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown kid: error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := Validator{Issuer: "auth", Audience: "albums", Leeway: 30 * time.Second, Now: func() time.Time { return now }}
+	ok := Claims{Issuer: "auth", Audience: []string{"web", "albums"}}
+
+	tests := []struct {
+		name   string
+		change func(*Claims)
+		want   error
+	}{
+		{"valid", func(c *Claims) {}, nil},
+		{"expired", func(c *Claims) { c.ExpiresAt = now.Add(-time.Minute).Unix() }, ErrExpired},
+		{"expired within leeway", func(c *Claims) { c.ExpiresAt = now.Add(-10 * time.Second).Unix() }, nil},
+		{"not before", func(c *Claims) { c.NotBefore = now.Add(time.Minute).Unix() }, ErrNotYetValid},
+		{"not before within leeway", func(c *Claims) { c.NotBefore = now.Add(10 * time.Second).Unix() }, nil},
+		{"issued in future", func(c *Claims) { c.IssuedAt = now.Add(time.Hour).Unix() }, ErrIssuedInFuture},
+		{"issuer", func(c *Claims) { c.Issuer = "other" }, ErrIssuer},
+		{"audience", func(c *Claims) { c.Audience = []string{"web"} }, ErrAudience},
+	}
+	for _, tt := range tests {
+		c := ok
+		tt.change(&c)
+		if err := v.Validate(c); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: Validate = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	v.RequireExpiry = true
+	if err := v.Validate(ok); !errors.Is(err, ErrMissingClaim) {
+		t.Errorf("RequireExpiry without exp: Validate = %v, want ErrMissingClaim", err)
+	}
+}
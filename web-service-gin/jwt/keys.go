@@ -0,0 +1,177 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+)
+
+// Supported values of the alg header.
+const (
+	HS256 = "HS256"
+	HS384 = "HS384"
+	HS512 = "HS512"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+// Key is a verification key and the one algorithm it may be used with.
+//
+// Key is a []byte secret for HS256/384/512, an *rsa.PublicKey for RS256 and
+// an *ecdsa.PublicKey on P-256 for ES256.
+type Key struct {
+	Alg string
+	Key any
+}
+
+// KeyFunc returns the verification key for a kid. Returning keys by ID is
+// what makes rotation work: sign new tokens with a new kid and keep the old
+// key until the tokens it signed have expired.
+type KeyFunc func(kid string) (Key, error)
+
+// KeySet is a fixed set of keys by kid.
+type KeySet map[string]Key
+
+// Lookup is a KeyFunc for the keys in s.
+func (s KeySet) Lookup(kid string) (Key, error) {
+	k, ok := s[kid]
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return k, nil
+}
+
+// signingMethod implements one alg.
+type signingMethod interface {
+	sign(input []byte, key any) ([]byte, error)
+	verify(input, sig []byte, key any) bool
+}
+
+func method(alg string) (signingMethod, error) {
+	switch alg {
+	case HS256:
+		return hmacMethod{sha256.New}, nil
+	case HS384:
+		return hmacMethod{sha512.New384}, nil
+	case HS512:
+		return hmacMethod{sha512.New}, nil
+	case RS256:
+		return rsaMethod{}, nil
+	case ES256:
+		return ecdsaMethod{}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrAlgorithm, alg)
+}
+
+var errKeyType = errors.New("wrong key type for algorithm")
+
+// hmacMethod is HS256/384/512: both sides share one secret.
+type hmacMethod struct {
+	hash func() hash.Hash
+}
+
+func (m hmacMethod) sign(input []byte, key any) ([]byte, error) {
+	secret, ok := key.([]byte)
+	if !ok {
+		return nil, errKeyType
+	}
+	h := hmac.New(m.hash, secret)
+	h.Write(input)
+	return h.Sum(nil), nil
+}
+
+func (m hmacMethod) verify(input, sig []byte, key any) bool {
+	want, err := m.sign(input, key)
+	return err == nil && hmac.Equal(sig, want) // constant time
+}
+
+// rsaMethod is RS256: RSASSA-PKCS1-v1_5 with SHA-256.
+type rsaMethod struct{}
+
+func (rsaMethod) sign(input []byte, key any) ([]byte, error) {
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errKeyType
+	}
+	digest := sha256.Sum256(input)
+	return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+}
+
+func (rsaMethod) verify(input, sig []byte, key any) bool {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return false
+	}
+	digest := sha256.Sum256(input)
+	return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+}
+
+// ecdsaMethod is ES256: ECDSA on P-256 with SHA-256. JWS encodes the
+// signature as R and S, 32 bytes each, not as ASN.1 like crypto/ecdsa does.
+type ecdsaMethod struct{}
+
+const es256Size = 32
+
+func (ecdsaMethod) sign(input []byte, key any) ([]byte, error) {
+	priv, ok := key.(*ecdsa.PrivateKey)
+	if !ok || priv.Curve != elliptic.P256() {
+		return nil, errKeyType
+	}
+	digest := sha256.Sum256(input)
+	r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 2*es256Size)
+	r.FillBytes(sig[:es256Size])
+	s.FillBytes(sig[es256Size:])
+	return sig, nil
+}
+
+func (ecdsaMethod) verify(input, sig []byte, key any) bool {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() || len(sig) != 2*es256Size {
+		return false
+	}
+	digest := sha256.Sum256(input)
+	r := new(big.Int).SetBytes(sig[:es256Size])
+	s := new(big.Int).SetBytes(sig[es256Size:])
+	return ecdsa.Verify(pub, digest[:], r, s)
+}
+
+// ParsePublicKeyPEM parses a PEM "PUBLIC KEY" (PKIX) block holding an RSA
+// or ECDSA key, as written by `openssl pkey -pubout`.
+func ParsePublicKeyPEM(data []byte) (any, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM block found")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// ParsePrivateKeyPEM parses a PEM "PRIVATE KEY" (PKCS #8), "RSA PRIVATE KEY"
+// (PKCS #1) or "EC PRIVATE KEY" (SEC 1) block.
+func ParsePrivateKeyPEM(data []byte) (any, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
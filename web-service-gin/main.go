@@ -1,51 +1,228 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
 	"net/http"
+	"os"
+	"time"
+
+	"example/web-service-gin/jwt"
+
+	"example.com/albums/albumdb"
+	"example.com/money"
 
 	"github.com/gin-gonic/gin"
 )
 
 // album represents data about a record album.
 type album struct {
-	ID     string  `json:"id"` // specify what a field’s name should be when the struct’s contents are serialized into JSON
-	Title  string  `json:"title"`
-	Artist string  `json:"artist"`
-	Price  float64 `json:"price"`
+	ID     string       `json:"id"` // specify what a field’s name should be when the struct’s contents are serialized into JSON
+	Title  string       `json:"title"`
+	Artist string       `json:"artist"`
+	Price  money.Amount `json:"price"` // in USD; sent as a JSON number, see albumJSON
 }
 
-// albums slice to seed record album data.
-var albums = []album{
-	{ID: "1", Title: "Blue Train", Artist: "John Coltrane", Price: 56.99},
-	{ID: "2", Title: "Jeru", Artist: "Gerry Mulligan", Price: 17.99},
-	{ID: "3", Title: "Sarah Vaughan and Clifford Brown", Artist: "Sarah Vaughan", Price: 39.99},
-}
+var (
+	store AlbumStore  // album storage used by the handlers.
+	ids   idGenerator // assigns the ID of every new album.
+)
+
+/**
+Run:
+go run .                                  // albums kept in memory on localhost:8080
+go run . -store=file -data=albums.json    // albums persisted to albums.json
+go run . -ids=uuid                        // new albums get UUIDs instead of 1, 2, 3...
+go run . -store=sql -db-migrate           // albums in SQLite (albums.db), pool stats at /debug/db
+go run . -store=sql -db-driver=mysql -db-dsn='user:pass@tcp(127.0.0.1:3306)/recordings?clientFoundRows=true' -db-max-open=20
+ALBUMS_ADDR=:9090 go run .                // every flag has an ALBUMS_* env var, see go run . -h
+go run . -tls-cert=cert.pem -tls-key=key.pem
+go run . -sign-keys=web:s3cret            // require requests signed as in package apisign
+go run . -jwt-keys=2024:HS256:s3cret      // require an Authorization: Bearer JWT
+
+curl -X POST -H 'Content-Type: text/csv' --data-binary @albums.csv localhost:8080/albums:bulk
+*/
 
 func main() {
+	cfg, err := loadConfig(os.Args[1:], os.Getenv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatal(err)
+	}
+
+	var db *sql.DB // set for -store=sql
+	if cfg.store == "sql" {
+		var d albumdb.Dialect
+		if db, d, err = openDB(cfg); err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		store, err = newSQLStore(ctx, db, d, cfg.dbMigrate)
+		cancel()
+	} else {
+		store, err = openStore(cfg.store, cfg.dataPath)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	existing, err := store.List()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ids, err = newIDGenerator(cfg.idStrategy, existing)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var middleware []gin.HandlerFunc
+	if cfg.signKeys != "" {
+		keys, err := parseKeys(cfg.signKeys)
+		if err != nil {
+			log.Fatal(err)
+		}
+		middleware = append(middleware, requireSignature(keys, cfg.signSkew))
+	}
+	if cfg.jwtKeys != "" {
+		keys, err := parseJWTKeys(cfg.jwtKeys)
+		if err != nil {
+			log.Fatal(err)
+		}
+		middleware = append(middleware, requireJWT(keys.Lookup, jwt.Validator{
+			Issuer:        cfg.jwtIssuer,
+			Audience:      cfg.jwtAudience,
+			Leeway:        cfg.jwtLeeway,
+			RequireExpiry: true,
+		}))
+	}
+
+	router := newRouter(middleware...)
+	ctx, stopProbe := context.WithCancel(context.Background())
+	defer stopProbe()
+	if db != nil {
+		prober := albumdb.NewProber(db, cfg.dbProbeInterval)
+		prober.Probe(ctx) // so /debug/db has a result from the start
+		go prober.Run(ctx)
+		router.GET("/debug/db", debugDB(db, prober))
+	}
+
+	// runServer attaches the router to an http.Server and returns after a
+	// graceful shutdown on Ctrl+C or SIGTERM.
+	if err := runServer(ctx, cfg, router); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newRouter returns a Gin router with the album routes registered
+// behind the given middleware.
+func newRouter(middleware ...gin.HandlerFunc) *gin.Engine {
 	router := gin.Default() // Initialize a Gin router using Default.
+	router.Use(middleware...)
 	router.GET("/albums", getAlbums)
 	router.GET("/albums/:id", getAlbumByID)
-	router.POST("/albums", postAlbums)
+	router.POST("/albums", idempotent(newIdempotencyCache(24*time.Hour)), postAlbums)
+	router.POST("/albums:action", albumsAction) // POST /albums:bulk
+	router.PUT("/albums/:id", putAlbum)
+	router.PATCH("/albums/:id", patchAlbum)
+	router.DELETE("/albums/:id", deleteAlbum)
+	return router
+}
 
-	router.Run("localhost:8080") //attach the router to an http.Server
+// openStore returns the AlbumStore selected by kind.
+func openStore(kind, path string) (AlbumStore, error) {
+	switch kind {
+	case "memory":
+		return newMemoryStore(seedAlbums()), nil
+	case "file":
+		return newFileStore(path)
+	default:
+		return nil, errors.New("unknown store " + kind + ", want memory, file or sql")
+	}
 }
 
-// getAlbums responds with the list of all albums as JSON.
+// getAlbums responds with the list of albums as JSON.
+//
+// Query parameters:
+//
+//	artist=John Coltrane     exact artist, ignoring case
+//	title~=train             title contains, ignoring case
+//	minPrice=10&maxPrice=50  inclusive price range
+//	sort=price,-title        sort keys, "-" for descending
+//	limit=20&cursor=...      page size and the next_cursor of the previous page
+//
+// Without limit or cursor the response is a plain JSON array, as before.
+// With them it is {"albums": [...], "next_cursor": "..."}, and a Link
+// header with rel="next" points at the following page.
 func getAlbums(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, albums) // in prod use  Context.JSON() instead
+	q, errs := parseAlbumQuery(c.Request.URL.Query())
+	if errs != nil {
+		respondDomainError(c, &ValidationError{Message: "invalid query", Fields: errs})
+		return
+	}
+
+	albums, err := store.List()
+	if err != nil {
+		respondDomainError(c, err)
+		return
+	}
+	page, next := q.apply(albums)
+	if !q.paginate {
+		if page == nil {
+			page = []album{} // [] rather than null
+		}
+		c.IndentedJSON(http.StatusOK, page) // in prod use  Context.JSON() instead
+		return
+	}
+
+	body := gin.H{"albums": page, "next_cursor": nil}
+	if page == nil {
+		body["albums"] = []album{}
+	}
+	if next != nil {
+		cursor := encodeCursor(c.Query("sort"), *next)
+		body["next_cursor"] = cursor
+		u := *c.Request.URL
+		params := u.Query()
+		params.Set("cursor", cursor)
+		u.RawQuery = params.Encode()
+		c.Header("Link", "<"+u.RequestURI()+`>; rel="next"`)
+	}
+	c.IndentedJSON(http.StatusOK, body)
 }
 
 // postAlbums adds an album from JSON received in the request body.
+// The server assigns the album ID and returns it in the Location header.
 func postAlbums(c *gin.Context) {
 	var newAlbum album
 
-	// Call BindJSON to bind the received JSON to newAlbum
-	if err := c.BindJSON(&newAlbum); err != nil {
+	// Call ShouldBindJSON to bind the received JSON to newAlbum.
+	// Unlike BindJSON it leaves writing the 400 response to us.
+	if err := c.ShouldBindJSON(&newAlbum); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if newAlbum.ID != "" {
+		respondDomainError(c, invalidAlbum(fieldError{Field: "id", Message: "is assigned by the server"}))
+		return
+	}
+	newAlbum.ID = ids.NewID()
+	if err := newAlbum.validate(); err != nil {
+		respondDomainError(c, err)
 		return
 	}
 
-	// Add the new album to the slice.
-	albums = append(albums, newAlbum)
+	// Add the new album to the store.
+	if err := store.Add(newAlbum); err != nil {
+		respondDomainError(c, err)
+		return
+	}
+	c.Header("Location", "/albums/"+newAlbum.ID)
 	c.IndentedJSON(http.StatusCreated, newAlbum)
 }
 
@@ -54,13 +231,94 @@ func postAlbums(c *gin.Context) {
 func getAlbumByID(c *gin.Context) {
 	id := c.Param("id")
 
-	// Loop over the list of albums, looking for
-	// an album whose ID value matches the parameter.
-	for _, a := range albums {
-		if a.ID == id {
-			c.IndentedJSON(http.StatusOK, a)
-			return
-		}
+	a, err := store.Get(id)
+	if err != nil {
+		respondDomainError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, a)
+}
+
+// putAlbum replaces the album whose ID matches the id parameter
+// with the album in the request body.
+func putAlbum(c *gin.Context) {
+	id := c.Param("id")
+
+	var a album
+	if err := c.ShouldBindJSON(&a); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	// The ID in the body is optional, but it must not move the album.
+	if a.ID != "" && a.ID != id {
+		respondDomainError(c, errIDMismatch)
+		return
+	}
+	a.ID = id
+	if err := a.validate(); err != nil {
+		respondDomainError(c, err)
+		return
+	}
+
+	if err := store.Update(a); err != nil {
+		respondDomainError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, a)
+}
+
+// patchAlbum applies the JSON merge patch (RFC 7396) in the request body
+// to the album whose ID matches the id parameter.
+func patchAlbum(c *gin.Context) {
+	id := c.Param("id")
+
+	a, err := store.Get(id)
+	if err != nil {
+		respondDomainError(c, err)
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidJSON, err.Error())
+		return
+	}
+
+	original, err := json.Marshal(a)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+	patched, err := mergePatch(original, patch)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidJSON, err.Error())
+		return
+	}
+	var updated album
+	if err := json.Unmarshal(patched, &updated); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if updated.ID != id {
+		respondDomainError(c, errIDMismatch)
+		return
+	}
+	if err := updated.validate(); err != nil {
+		respondDomainError(c, err)
+		return
+	}
+
+	if err := store.Update(updated); err != nil {
+		respondDomainError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, updated)
+}
+
+// deleteAlbum removes the album whose ID matches the id parameter.
+func deleteAlbum(c *gin.Context) {
+	if err := store.Delete(c.Param("id")); err != nil {
+		respondDomainError(c, err)
+		return
 	}
-	c.IndentedJSON(http.StatusNotFound, gin.H{"message": "album not found"})
+	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/money"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter points the handlers at a fresh seeded memoryStore and
+// returns a router with the album routes registered.
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	store = newMemoryStore(seedAlbums())
+	ids = newMonotonicIDs(seedAlbums())
+	return newRouter()
+}
+
+// serve sends a request with the given body to router and returns the recorder.
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPutAlbum(t *testing.T) {
+	router := newTestRouter()
+	w := serve(router, http.MethodPut, "/albums/2", `{"title":"Jeru (Remastered)","artist":"Gerry Mulligan","price":19.99}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT /albums/2 = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if a, _ := store.Get("2"); a.Title != "Jeru (Remastered)" || a.Price != money.MustParse("19.99", money.USD) {
+		t.Errorf("album 2 = %+v, want replaced title and price", a)
+	}
+
+	if w := serve(router, http.MethodPut, "/albums/99", `{"title":"x","artist":"y","price":1}`); w.Code != http.StatusNotFound {
+		t.Errorf("PUT /albums/99 = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := serve(router, http.MethodPut, "/albums/2", `{"id":"3","title":"x","artist":"y","price":1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT /albums/2 with id 3 = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestPatchAlbum(t *testing.T) {
+	router := newTestRouter()
+	w := serve(router, http.MethodPatch, "/albums/1", `{"price":49.99}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH /albums/1 = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var got album
+	json.Unmarshal(w.Body.Bytes(), &got)
+	want := album{ID: "1", Title: "Blue Train", Artist: "John Coltrane", Price: money.MustParse("49.99", money.USD)}
+	if got != want {
+		t.Errorf("PATCH /albums/1 body = %+v, want %+v", got, want)
+	}
+
+	// Numbers aren't rounded through float64.
+	w = serve(router, http.MethodPatch, "/albums/1", `{"price":9007199254740993.01}`)
+	if a, _ := store.Get("1"); w.Code != http.StatusOK || a.Price != money.MustParse("9007199254740993.01", money.USD) {
+		t.Errorf("PATCH a large price = %d, price %v, want 9007199254740993.01: %s", w.Code, a.Price, w.Body)
+	}
+
+	// null removes the member, and artist is required.
+	if w := serve(router, http.MethodPatch, "/albums/1", `{"artist":null}`); w.Code != http.StatusBadRequest {
+		t.Errorf(`PATCH {"artist":null} = %d, want %d`, w.Code, http.StatusBadRequest)
+	}
+	if a, _ := store.Get("1"); a.Artist != "John Coltrane" {
+		t.Errorf("artist after rejected patch = %q, want unchanged", a.Artist)
+	}
+
+	if w := serve(router, http.MethodPatch, "/albums/99", `{"price":1}`); w.Code != http.StatusNotFound {
+		t.Errorf("PATCH /albums/99 = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestDeleteAlbum(t *testing.T) {
+	router := newTestRouter()
+	if w := serve(router, http.MethodDelete, "/albums/3", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /albums/3 = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	w := serve(router, http.MethodDelete, "/albums/3", "")
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "album not found") {
+		t.Errorf("second DELETE /albums/3 = %d %s, want 404 album not found", w.Code, w.Body)
+	}
+}
+
+func TestPostAlbumsValidation(t *testing.T) {
+	tests := []struct {
+		body       string
+		wantStatus int
+		wantCode   string
+		wantFields []string
+	}{
+		{`{"title":"Giant Steps","artist":"John Coltrane","price":63.99}`, http.StatusCreated, "", nil},
+		{`{"title":`, http.StatusBadRequest, codeInvalidJSON, nil},
+		{`{"title":"","artist":" ","price":-1}`, http.StatusBadRequest, codeValidationFailed, []string{"title", "artist", "price"}},
+		{`{"title":"t","artist":"a","price":1.999}`, http.StatusBadRequest, codeValidationFailed, []string{"price"}},
+		{`{"id":"9","title":"t","artist":"a","price":1}`, http.StatusBadRequest, codeValidationFailed, []string{"id"}},
+	}
+	router := newTestRouter()
+	for _, tt := range tests {
+		w := serve(router, http.MethodPost, "/albums", tt.body)
+		if w.Code != tt.wantStatus {
+			t.Errorf("POST %s = %d, want %d", tt.body, w.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantCode == "" {
+			continue
+		}
+		var got apiError
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Errorf("POST %s body %s: %v", tt.body, w.Body, err)
+			continue
+		}
+		var fields []string
+		for _, f := range got.Fields {
+			fields = append(fields, f.Field)
+		}
+		if got.Code != tt.wantCode || strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+			t.Errorf("POST %s = %+v, want code %s fields %v", tt.body, got, tt.wantCode, tt.wantFields)
+		}
+	}
+}
+
+func TestPostAlbumsAssignsID(t *testing.T) {
+	router := newTestRouter()
+	w := serve(router, http.MethodPost, "/albums", `{"title":"Giant Steps","artist":"John Coltrane","price":63.99}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/albums/4" {
+		t.Fatalf("POST /albums = %d Location %q, want 201 /albums/4", w.Code, w.Header().Get("Location"))
+	}
+	if a, err := store.Get("4"); err != nil || a.Title != "Giant Steps" {
+		t.Errorf(`Get("4") = %+v, %v, want Giant Steps`, a, err)
+	}
+}
+
+func TestPostAlbumsIdempotencyKey(t *testing.T) {
+	router := newTestRouter()
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"title":"Giant Steps","artist":"John Coltrane","price":63.99}`
+
+	first := post("k1", body)
+	second := post("k1", body)
+	if second.Code != first.Code || second.Body.String() != first.Body.String() ||
+		second.Header().Get("Location") != first.Header().Get("Location") {
+		t.Errorf("replayed response = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replayed response is missing Idempotent-Replayed: true")
+	}
+	if albums, _ := store.List(); len(albums) != len(seedAlbums())+1 {
+		t.Errorf("len(List()) = %d, want one new album", len(albums))
+	}
+
+	if w := post("k1", `{"title":"Other","artist":"x","price":1}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with a different body = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+// TestIdempotencyRetryDuringSlowRequest retries a request, concurrently,
+// while the original is still being handled and as it completes; run it
+// with -race.
+func TestIdempotencyRetryDuringSlowRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	started, release := make(chan struct{}), make(chan struct{})
+	router := gin.New()
+	router.POST("/slow", idempotent(newIdempotencyCache(time.Hour)), func(c *gin.Context) {
+		close(started)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{"id": "1"})
+	})
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/slow", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- post() }()
+	<-started // the first request has claimed the key
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if w := post(); w.Code != http.StatusConflict && w.Code != http.StatusCreated {
+					t.Errorf("retry = %d %s, want 409 or the replayed 201", w.Code, w.Body)
+					return
+				}
+			}
+		}()
+	}
+	close(release)
+	wg.Wait()
+	if w := <-first; w.Code != http.StatusCreated {
+		t.Fatalf("first POST = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if w := post(); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after completion = %d, replayed %q; want the recorded 201", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+// TestIdempotencyPanickingHandler checks that a key whose handler panicked
+// can be retried rather than staying in flight for good.
+func TestIdempotencyPanickingHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	panics := true
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	router.POST("/flaky", idempotent(newIdempotencyCache(time.Hour)), func(c *gin.Context) {
+		if panics {
+			panics = false
+			panic("flaky")
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": "1"})
+	})
+	post := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/flaky", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post(); code != http.StatusInternalServerError {
+		t.Fatalf("panicking POST = %d, want %d", code, http.StatusInternalServerError)
+	}
+	if code := post(); code != http.StatusCreated {
+		t.Errorf("retry after the panic = %d, want %d", code, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyCacheExpires(t *testing.T) {
+	cache := newIdempotencyCache(time.Minute)
+	now := time.Now()
+	for i, key := range []string{"a", "b", "c"} {
+		r := &idempotentResponse{expires: now.Add(time.Duration(i) * time.Minute)}
+		cache.entries[key] = r
+		cache.expiry = append(cache.expiry, expiringKey{key, r})
+	}
+	cache.entries["d"] = &idempotentResponse{inFlight: true} // not queued until recorded
+
+	cache.expire(now.Add(90 * time.Second))
+	if len(cache.entries) != 2 || cache.entries["c"] == nil || cache.entries["d"] == nil || len(cache.expiry) != 1 {
+		t.Errorf("after expiring, entries = %v, queue = %v; want c and the in-flight d", cache.entries, cache.expiry)
+	}
+}
+
+func TestPostAlbumsBulk(t *testing.T) {
+	router := newTestRouter()
+	bulk := func(contentType, body string) bulkResult {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/albums:bulk", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var result bulkResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); w.Code != http.StatusOK || err != nil {
+			t.Fatalf("POST /albums:bulk = %d %s", w.Code, w.Body)
+		}
+		return result
+	}
+	rows := func(r bulkResult) (codes []string) {
+		for _, e := range r.Errors {
+			codes = append(codes, strconv.Itoa(e.Row)+":"+e.Code)
+		}
+		return codes
+	}
+
+	r := bulk("text/csv", "title,artist,price\nGiant Steps,John Coltrane,63.99\nBad,X,abc\n,Nobody,1\nKind of Blue,Miles Davis,12.5\n")
+	if got := rows(r); r.Imported != 2 || strings.Join(got, " ") != "2:invalid_row 3:validation_failed" {
+		t.Errorf("csv import = %d imported, errors %v", r.Imported, got)
+	}
+	if albums, _ := store.List(); len(albums) != len(seedAlbums())+2 {
+		t.Errorf("len(List()) = %d, want 2 new albums", len(albums))
+	}
+
+	r = bulk("application/x-ndjson", "{\"title\":\"A\",\"artist\":\"B\",\"price\":1}\n{\"id\":\"9\",\"title\":\"C\",\"artist\":\"D\",\"price\":1}\n")
+	if got := rows(r); r.Imported != 1 || strings.Join(got, " ") != "2:validation_failed" {
+		t.Errorf("jsonl import = %d imported, errors %v", r.Imported, got)
+	}
+
+	r = bulk("application/json", `[{"title":"E","artist":"F","price":2},{"title":`)
+	if got := rows(r); r.Imported != 1 || strings.Join(got, " ") != "2:invalid_row" {
+		t.Errorf("truncated json import = %d imported, errors %v", r.Imported, got)
+	}
+
+	if w := serve(router, http.MethodPost, "/albums:nope", "[]"); w.Code != http.StatusNotFound {
+		t.Errorf("POST /albums:nope = %d, want 404", w.Code)
+	}
+}
+
+func TestGetAlbumsQuery(t *testing.T) {
+	router := newTestRouter()
+	store.Add(album{ID: "4", Title: "Giant Steps", Artist: "John Coltrane", Price: money.MustParse("63.99", money.USD)})
+
+	tests := []struct {
+		query string
+		want  []string // album IDs in order
+	}{
+		{"", []string{"1", "2", "3", "4"}},
+		{"?artist=john%20coltrane", []string{"1", "4"}},
+		{"?title~=STEP", []string{"4"}},
+		{"?minPrice=20&maxPrice=60", []string{"1", "3"}},
+		{"?sort=-price", []string{"4", "1", "3", "2"}},
+		{"?sort=artist,-title", []string{"2", "4", "1", "3"}},
+	}
+	for _, tt := range tests {
+		w := serve(router, http.MethodGet, "/albums"+tt.query, "")
+		var got []album
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Errorf("GET /albums%s: %v: %s", tt.query, err, w.Body)
+			continue
+		}
+		var gotIDs []string
+		for _, a := range got {
+			gotIDs = append(gotIDs, a.ID)
+		}
+		if strings.Join(gotIDs, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("GET /albums%s = %v, want %v", tt.query, gotIDs, tt.want)
+		}
+	}
+
+	if w := serve(router, http.MethodGet, "/albums?sort=label", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("GET /albums?sort=label = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGetAlbumsPagination(t *testing.T) {
+	router := newTestRouter()
+	store.Add(album{ID: "4", Title: "Giant Steps", Artist: "John Coltrane", Price: money.MustParse("63.99", money.USD)})
+
+	var gotIDs []string
+	target := "/albums?sort=-price&limit=3"
+	for pages := 0; target != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not end")
+		}
+		w := serve(router, http.MethodGet, target, "")
+		var body struct {
+			Albums     []album `json:"albums"`
+			NextCursor *string `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("GET %s: %v: %s", target, err, w.Body)
+		}
+		for _, a := range body.Albums {
+			gotIDs = append(gotIDs, a.ID)
+		}
+		target = ""
+		if body.NextCursor != nil {
+			link := w.Header().Get("Link")
+			if !strings.Contains(link, *body.NextCursor) || !strings.HasSuffix(link, `rel="next"`) {
+				t.Errorf("Link = %q, want next page with cursor %s", link, *body.NextCursor)
+			}
+			target = "/albums?sort=-price&limit=3&cursor=" + *body.NextCursor
+		}
+	}
+	if want := "4,1,3,2"; strings.Join(gotIDs, ",") != want {
+		t.Errorf("paged IDs = %v, want %s", gotIDs, want)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// mergePatch applies a JSON merge patch (RFC 7396) to the JSON document
+// original and returns the patched document.
+//
+// A patch member set to null deletes the field, an object is merged
+// recursively, and any other value replaces the field as a whole.
+func mergePatch(original, patch []byte) ([]byte, error) {
+	doc, err := decodeValue(original)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decodeValue(patch)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(doc, p))
+}
+
+// decodeValue decodes the JSON value data, keeping numbers as json.Number:
+// a float64 would round prices such as 9007199254740993.01.
+func decodeValue(data []byte) (any, error) {
+	var v any
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, errors.New("data after the JSON value")
+	}
+	return v, nil
+}
+
+func mergeValue(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch // a non-object patch replaces the target
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergeValue(targetObj[k], v)
+	}
+	return targetObj
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"example.com/albums/albumio"
+	"example.com/money"
+)
+
+// albumJSON is the wire form of an album. The price stays a JSON number, as
+// it was when it was a float64, but its text is parsed exactly rather than
+// through a float.
+type albumJSON struct {
+	ID     string      `json:"id"`
+	Title  string      `json:"title"`
+	Artist string      `json:"artist"`
+	Price  json.Number `json:"price"`
+}
+
+func (a album) MarshalJSON() ([]byte, error) {
+	return json.Marshal(albumJSON{ID: a.ID, Title: a.Title, Artist: a.Artist, Price: json.Number(a.Price.Decimal())})
+}
+
+// UnmarshalJSON reads an album. A price with more than two decimals is a
+// *ValidationError rather than being rounded.
+func (a *album) UnmarshalJSON(b []byte) error {
+	var v albumJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	price, err := albumio.ParsePrice(string(v.Price))
+	if err != nil {
+		return priceError(err)
+	}
+	*a = album{ID: v.ID, Title: v.Title, Artist: v.Artist, Price: price}
+	return nil
+}
+
+// priceError turns the error of a price with too many decimals into a
+// *ValidationError, and returns other errors as they are.
+func priceError(err error) error {
+	if errors.Is(err, money.ErrPrecision) {
+		return invalidAlbum(fieldError{Field: "price", Message: "must have at most two decimals"})
+	}
+	return err
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/money"
+)
+
+// maxLimit caps the page size a client can ask for with ?limit=.
+const maxLimit = 100
+
+// albumQuery is a parsed GET /albums query string.
+type albumQuery struct {
+	artist    string        // artist=, exact match ignoring case
+	titleLike string        // title~=, substring match ignoring case
+	minPrice  *money.Amount // minPrice=, inclusive
+	maxPrice  *money.Amount // maxPrice=, inclusive
+	sort      []sortKey
+	limit     int // 0 means no pagination
+	after     *album
+	paginate  bool // limit or cursor was given
+}
+
+// sortKey is one comma-separated entry of ?sort=, e.g. "-title".
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// pageCursor is what an opaque next_cursor decodes to: the sort it was
+// issued for and the sort keys of the last album on the previous page.
+type pageCursor struct {
+	Sort string `json:"s"`
+	Last album  `json:"l"`
+}
+
+// parseAlbumQuery reads the filter, sort and pagination parameters from q.
+func parseAlbumQuery(q url.Values) (albumQuery, []fieldError) {
+	var aq albumQuery
+	var errs []fieldError
+
+	aq.artist = q.Get("artist")
+	aq.titleLike = q.Get("title~")
+	for _, p := range []struct {
+		name string
+		dst  **money.Amount
+	}{{"minPrice", &aq.minPrice}, {"maxPrice", &aq.maxPrice}} {
+		if v := q.Get(p.name); v != "" {
+			// Round rather than reject extra decimals: a bound of 9.999
+			// still means something.
+			price, err := money.ParseRound(v, money.USD, money.HalfEven)
+			if err != nil {
+				errs = append(errs, fieldError{Field: p.name, Message: "must be a number"})
+				continue
+			}
+			*p.dst = &price
+		}
+	}
+
+	sortParam := q.Get("sort")
+	if sortParam != "" {
+		for _, f := range strings.Split(sortParam, ",") {
+			k := sortKey{field: f}
+			if strings.HasPrefix(f, "-") {
+				k = sortKey{field: f[1:], desc: true}
+			}
+			switch k.field {
+			case "id", "title", "artist", "price":
+				aq.sort = append(aq.sort, k)
+			default:
+				errs = append(errs, fieldError{Field: "sort", Message: "unknown field " + strconv.Quote(k.field)})
+			}
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			errs = append(errs, fieldError{Field: "limit", Message: "must be between 1 and " + strconv.Itoa(maxLimit)})
+		}
+		aq.limit = n
+		aq.paginate = true
+	}
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		switch {
+		case err != nil:
+			errs = append(errs, fieldError{Field: "cursor", Message: "is not a valid cursor"})
+		case c.Sort != sortParam:
+			errs = append(errs, fieldError{Field: "cursor", Message: "was issued for a different sort"})
+		default:
+			aq.after = &c.Last
+		}
+		aq.paginate = true
+	}
+	return aq, errs
+}
+
+// match reports whether a passes the query's filters.
+func (aq albumQuery) match(a album) bool {
+	if aq.artist != "" && !strings.EqualFold(a.Artist, aq.artist) {
+		return false
+	}
+	if aq.titleLike != "" && !strings.Contains(strings.ToLower(a.Title), strings.ToLower(aq.titleLike)) {
+		return false
+	}
+	if aq.minPrice != nil && comparePrices(a.Price, *aq.minPrice) < 0 {
+		return false
+	}
+	if aq.maxPrice != nil && comparePrices(a.Price, *aq.maxPrice) > 0 {
+		return false
+	}
+	return true
+}
+
+// apply filters and sorts albums and cuts out the requested page. next is the
+// last album of the page when more albums follow it, or nil.
+func (aq albumQuery) apply(albums []album) (page []album, next *album) {
+	for _, a := range albums {
+		if aq.match(a) {
+			page = append(page, a)
+		}
+	}
+	by := albumsByKeys{albums: page, keys: aq.sort}
+	sort.Sort(by)
+
+	if aq.after != nil {
+		// Skip everything up to and including the cursor album. Because the
+		// order is total this still works if that album was since deleted.
+		i := sort.Search(len(page), func(i int) bool { return by.compare(page[i], *aq.after) > 0 })
+		page = page[i:]
+	}
+	if aq.limit > 0 && len(page) > aq.limit {
+		page = page[:aq.limit]
+		next = &page[len(page)-1]
+	}
+	return page, next
+}
+
+// albumsByKeys sorts albums by several keys in turn, like Queue in
+// test/sort.go but with a list of keys. A descending key is the Reverse
+// trick applied to one key only. ID is always the last key so the order
+// is total, which cursors depend on.
+type albumsByKeys struct {
+	albums []album
+	keys   []sortKey
+}
+
+func (s albumsByKeys) Len() int           { return len(s.albums) }
+func (s albumsByKeys) Swap(i, j int)      { s.albums[i], s.albums[j] = s.albums[j], s.albums[i] }
+func (s albumsByKeys) Less(i, j int) bool { return s.compare(s.albums[i], s.albums[j]) < 0 }
+
+// compare returns -1, 0 or 1 as a sorts before, with or after b.
+func (s albumsByKeys) compare(a, b album) int {
+	for _, k := range s.keys {
+		c := compareField(k.field, a, b)
+		if k.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareField("id", a, b)
+}
+
+func compareField(field string, a, b album) int {
+	switch field {
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "artist":
+		return strings.Compare(a.Artist, b.Artist)
+	case "price":
+		return comparePrices(a.Price, b.Price)
+	default: // id
+		// Compare numeric IDs as numbers so "10" sorts after "9".
+		x, errX := strconv.ParseUint(a.ID, 10, 64)
+		y, errY := strconv.ParseUint(b.ID, 10, 64)
+		switch {
+		case errX == nil && errY == nil:
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		case errX == nil: // numbers before other IDs
+			return -1
+		case errY == nil:
+			return 1
+		}
+		return strings.Compare(a.ID, b.ID)
+	}
+}
+
+// comparePrices compares two album prices. They are all in USD, so
+// comparing minor units is enough and can't fail like Amount.Cmp.
+func comparePrices(a, b money.Amount) int {
+	return cmp.Compare(a.Minor(), b.Minor())
+}
+
+// encodeCursor returns the opaque cursor for the page after last.
+func encodeCursor(sortParam string, last album) string {
+	b, _ := json.Marshal(pageCursor{Sort: sortParam, Last: last})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runServer runs handler on an http.Server configured by cfg until ctx is
+// canceled or SIGINT/SIGTERM arrives. It then stops accepting connections and
+// waits up to cfg.shutdownTimeout for in-flight requests to finish.
+func runServer(ctx context.Context, cfg config, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:         cfg.addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.readTimeout,
+		WriteTimeout: cfg.writeTimeout,
+		IdleTimeout:  cfg.idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		if cfg.tlsCert != "" {
+			errc <- srv.ListenAndServeTLS(cfg.tlsCert, cfg.tlsKey)
+		} else {
+			errc <- srv.ListenAndServe()
+		}
+	}()
+	log.Printf("listening on %s", cfg.addr)
+
+	select {
+	case err := <-errc: // failed to start, e.g. the address is in use
+		return err
+	case <-ctx.Done():
+	}
+	stop() // a second Ctrl+C kills the process right away
+
+	log.Printf("shutting down, waiting up to %v for requests to finish", cfg.shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+	defer cancel() // 确保在函数结束时取消context
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"example/web-service-gin/apisign"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyStore looks up how to verify an API client's signatures.
+type KeyStore interface {
+	// Verifier returns the verifier for appID's requests signed with method,
+	// or false if appID is unknown or doesn't use that method.
+	Verifier(appID, method string) (apisign.Verifier, bool)
+}
+
+// clientKey is what the server knows about one client: either the shared
+// secret for the HMAC methods or the client's Ed25519 public key.
+type clientKey struct {
+	secret    string
+	publicKey ed25519.PublicKey
+}
+
+// staticKeys is a KeyStore backed by a fixed map from app_id to key.
+type staticKeys map[string]clientKey
+
+func (k staticKeys) Verifier(appID, method string) (apisign.Verifier, bool) {
+	key, ok := k[appID]
+	if !ok {
+		return nil, false
+	}
+	if method == apisign.MethodEd25519 {
+		return apisign.Ed25519Verifier{Key: key.publicKey}, key.publicKey != nil
+	}
+	if key.secret == "" {
+		return nil, false
+	}
+	v, err := apisign.NewHMAC(method, key.secret)
+	return v, err == nil
+}
+
+// parseKeys parses a list of keys separated by commas. Each is either
+// app_id:secret for the HMAC methods or app_id:ed25519:<hex public key>,
+// e.g. "web:s3cret,mobile:ed25519:3d4017c3e8...".
+func parseKeys(s string) (staticKeys, error) {
+	keys := staticKeys{}
+	for _, pair := range strings.Split(s, ",") {
+		appID, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || appID == "" || key == "" {
+			return nil, fmt.Errorf("sign keys: %q is not app_id:secret", pair)
+		}
+		if pub, ok := strings.CutPrefix(key, apisign.MethodEd25519+":"); ok {
+			b, err := hex.DecodeString(pub)
+			if err != nil || len(b) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("sign keys: %s has a bad ed25519 public key", appID)
+			}
+			keys[appID] = clientKey{publicKey: b}
+			continue
+		}
+		keys[appID] = clientKey{secret: key}
+	}
+	return keys, nil
+}
+
+// nonceCache remembers the nonces it has seen for ttl, which blocks a
+// captured request from being replayed while its timestamp is still fresh.
+type nonceCache struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	seen map[string]time.Time // nonce -> when it was first seen
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// add records nonce and reports whether it was new.
+func (c *nonceCache) add(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for n, t := range c.seen {
+		if now.Sub(t) > c.ttl {
+			delete(c.seen, n)
+		}
+	}
+	if _, ok := c.seen[nonce]; ok {
+		return false
+	}
+	c.seen[nonce] = now
+	return true
+}
+
+// requireSignature returns a middleware that only lets through requests
+// signed as described in package apisign, over their method, path and the
+// parameters chosen by apisign.RequestParams. app_id, timestamp, nonce and sign are required;
+// the timestamp must be within maxSkew of the server clock, and a nonce is
+// accepted once per app_id.
+func requireSignature(keys KeyStore, maxSkew time.Duration) gin.HandlerFunc {
+	// A nonce only needs remembering while its timestamp would pass the skew check.
+	nonces := newNonceCache(2 * maxSkew)
+
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			respondError(c, http.StatusBadRequest, codeInvalidJSON, err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body)) // let the handler read it again
+		params, err := apisign.RequestParams(c.Request, body)
+		if errors.Is(err, apisign.ErrUnsignedBody) {
+			respondError(c, http.StatusUnauthorized, codeSignatureMissing, err.Error(),
+				fieldError{Field: apisign.ParamBodyHash, Message: "is required for this content type"})
+			return
+		}
+		if err != nil {
+			respondError(c, http.StatusBadRequest, codeInvalidJSON, err.Error())
+			return
+		}
+		for _, name := range []string{apisign.ParamAppID, apisign.ParamTimestamp, apisign.ParamNonce, apisign.ParamSign} {
+			if params[name] == "" {
+				respondError(c, http.StatusUnauthorized, codeSignatureMissing, "request is not signed",
+					fieldError{Field: name, Message: "is required"})
+				return
+			}
+		}
+
+		appID := params[apisign.ParamAppID]
+		method := params[apisign.ParamSignMethod]
+		if method == "" {
+			// Signed as the default too, so the method verified with is
+			// always the one the signature covers.
+			method = apisign.MethodHMACSHA256
+			params[apisign.ParamSignMethod] = method
+		}
+		verifier, ok := keys.Verifier(appID, method)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, codeUnknownClient, "unknown app_id or sign_method")
+			return
+		}
+
+		now := time.Now()
+		ts, err := strconv.ParseInt(params[apisign.ParamTimestamp], 10, 64)
+		if err != nil {
+			respondError(c, http.StatusUnauthorized, codeTimestampOutOfRange, "timestamp must be Unix seconds")
+			return
+		}
+		if skew := now.Sub(time.Unix(ts, 0)); skew > maxSkew || skew < -maxSkew {
+			respondError(c, http.StatusUnauthorized, codeTimestampOutOfRange,
+				fmt.Sprintf("timestamp is more than %v away from server time", maxSkew))
+			return
+		}
+
+		canonical := apisign.CanonicalString(c.Request.Method, c.Request.URL.EscapedPath(), params)
+		if !verifier.Verify(canonical, params[apisign.ParamSign]) {
+			respondError(c, http.StatusUnauthorized, codeSignatureInvalid, "signature does not match")
+			return
+		}
+		if h := params[apisign.ParamBodyHash]; h != "" && !hmac.Equal([]byte(h), []byte(apisign.BodyHash(body))) {
+			respondError(c, http.StatusUnauthorized, codeSignatureInvalid, "body does not match body_sha256")
+			return
+		}
+		// Only record the nonce once the signature is known to be good,
+		// so nobody else can use up a client's nonces.
+		if !nonces.add(appID+"\x00"+params[apisign.ParamNonce], now) {
+			respondError(c, http.StatusUnauthorized, codeNonceReplayed, "nonce was already used")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/money"
+
+	"example/web-service-gin/apisign"
+)
+
+func TestRequireSignature(t *testing.T) {
+	newTestRouter()
+	router := newRouter(requireSignature(staticKeys{"web": {secret: "s3cret"}}, time.Minute))
+
+	// signedQueryFor returns the query string for params plus a valid
+	// signature of a method request to path, covering any JSON body members
+	// in body as well.
+	signedQueryFor := func(method, path string, params, body map[string]string, key string) string {
+		all := map[string]string{}
+		q := url.Values{}
+		for k, v := range params {
+			all[k] = v
+			q.Set(k, v)
+		}
+		for k, v := range body {
+			all[k] = v
+		}
+		signer, _ := apisign.NewHMAC(apisign.MethodHMACSHA256, key)
+		q.Set(apisign.ParamSign, signer.Sign(apisign.CanonicalString(method, path, all)))
+		return q.Encode()
+	}
+	signedQuery := func(params, body map[string]string, key string) string {
+		return signedQueryFor(http.MethodGet, "/albums", params, body, key)
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	params := func(nonce, ts string) map[string]string {
+		return map[string]string{"app_id": "web", "timestamp": ts, "nonce": nonce, "artist": "John Coltrane"}
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"signed GET", http.MethodGet, signedQuery(params("n1", now), nil, "s3cret"), "", http.StatusOK, ""},
+		{"replayed nonce", http.MethodGet, signedQuery(params("n1", now), nil, "s3cret"), "", http.StatusUnauthorized, codeNonceReplayed},
+		{"wrong key", http.MethodGet, signedQuery(params("n2", now), nil, "guess"), "", http.StatusUnauthorized, codeSignatureInvalid},
+		{"stale timestamp", http.MethodGet, signedQuery(params("n3", "1712345678"), nil, "s3cret"), "", http.StatusUnauthorized, codeTimestampOutOfRange},
+		{"unsigned", http.MethodGet, "artist=x", "", http.StatusUnauthorized, codeSignatureMissing},
+		{"unknown app", http.MethodGet, signedQuery(map[string]string{"app_id": "other", "timestamp": now, "nonce": "n4"}, nil, "s3cret"), "", http.StatusUnauthorized, codeUnknownClient},
+		{
+			"signed JSON body", http.MethodPost,
+			signedQueryFor(http.MethodPost, "/albums", map[string]string{"app_id": "web", "timestamp": now, "nonce": "n5"},
+				map[string]string{"title": "Giant Steps", "artist": "John Coltrane", "price": "63.99"}, "s3cret"),
+			`{"title":"Giant Steps","artist":"John Coltrane","price":63.99}`, http.StatusCreated, "",
+		},
+		{
+			"tampered JSON body", http.MethodPost,
+			signedQueryFor(http.MethodPost, "/albums", map[string]string{"app_id": "web", "timestamp": now, "nonce": "n6"},
+				map[string]string{"title": "Giant Steps", "artist": "John Coltrane", "price": "63.99"}, "s3cret"),
+			`{"title":"Giant Steps","artist":"John Coltrane","price":0.99}`, http.StatusUnauthorized, codeSignatureInvalid,
+		},
+	}
+	for _, tt := range tests {
+		w := serve(router, tt.method, "/albums?"+tt.query, tt.body)
+		if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantCode) {
+			t.Errorf("%s: %s = %d %s, want %d %s", tt.name, tt.method, w.Code, w.Body, tt.wantStatus, tt.wantCode)
+		}
+	}
+
+	// A value holding & and = can't pass for other parameters: the signature
+	// of nonce=n9 with sign_method=hmac-sha256 doesn't verify for a request
+	// whose nonce is "n9&sign_method=hmac-sha256" and whose sign_method is
+	// left to default, which would otherwise get past the nonce cache.
+	captured, _ := url.ParseQuery(signedQuery(map[string]string{"app_id": "web", "timestamp": now, "nonce": "n9", "sign_method": "hmac-sha256"}, nil, "s3cret"))
+	if w := serve(router, http.MethodGet, "/albums?"+captured.Encode(), ""); w.Code != http.StatusOK {
+		t.Fatalf("captured request = %d %s, want %d", w.Code, w.Body, http.StatusOK)
+	}
+	replay := url.Values{
+		"app_id":    {"web"},
+		"timestamp": {now},
+		"nonce":     {"n9&sign_method=hmac-sha256"},
+		"sign":      {captured.Get("sign")},
+	}
+	if w := serve(router, http.MethodGet, "/albums?"+replay.Encode(), ""); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), codeSignatureInvalid) {
+		t.Errorf("replay with sign_method in the nonce = %d %s, want %d %s", w.Code, w.Body, http.StatusUnauthorized, codeSignatureInvalid)
+	}
+
+	// A GET's signature doesn't carry over to another method or path.
+	getQuery := signedQuery(params("n7", now), nil, "s3cret")
+	if w := serve(router, http.MethodDelete, "/albums/1?"+getQuery, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("GET signature on DELETE /albums/1 = %d %s, want %d", w.Code, w.Body, http.StatusUnauthorized)
+	}
+
+	// A body that is neither form nor JSON must be covered by body_sha256.
+	query := signedQueryFor(http.MethodPost, "/albums", map[string]string{"app_id": "web", "timestamp": now, "nonce": "n8"}, nil, "s3cret")
+	req := httptest.NewRequest(http.MethodPost, "/albums?"+query, strings.NewReader(`{"title":"evil","artist":"x","price":1}`))
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), codeSignatureMissing) {
+		t.Errorf("text/plain body without body_sha256 = %d %s, want %d %s", w.Code, w.Body, http.StatusUnauthorized, codeSignatureMissing)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := parseKeys("web:s3cret, batch:0ther")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, ok := keys["batch"]; !ok || k.secret != "0ther" {
+		t.Errorf(`keys["batch"] = %+v, %v, want secret "0ther"`, k, ok)
+	}
+	if _, err := parseKeys("web"); err == nil {
+		t.Error(`parseKeys("web"): want error`)
+	}
+}
+
+// TestClientSignsForServer sends requests through apisign.Client to a server
+// running requireSignature, once per signature method.
+func TestClientSignsForServer(t *testing.T) {
+	newTestRouter()
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	keys := staticKeys{
+		"web":    {secret: "s3cret"},
+		"mobile": {publicKey: pub},
+	}
+	srv := httptest.NewServer(newRouter(requireSignature(keys, time.Minute)))
+	defer srv.Close()
+
+	sha512Signer, _ := apisign.NewHMAC(apisign.MethodHMACSHA512, "s3cret")
+	sha256Signer, _ := apisign.NewHMAC(apisign.MethodHMACSHA256, "s3cret")
+	clients := map[string]*apisign.Client{
+		"hmac-sha256": apisign.NewClient("web", sha256Signer),
+		"hmac-sha512": apisign.NewClient("web", sha512Signer),
+		"ed25519":     apisign.NewClient("mobile", apisign.Ed25519Signer{Key: priv}),
+	}
+	for name, client := range clients {
+		resp, err := client.Get(srv.URL + "/albums?artist=John%20Coltrane")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: GET = %d, want %d", name, resp.StatusCode, http.StatusOK)
+		}
+
+		resp, err = client.PostJSON(srv.URL+"/albums", album{Title: "Giant Steps " + name, Artist: "John Coltrane", Price: money.MustParse("63.99", money.USD)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Errorf("%s: POST = %d, want %d", name, resp.StatusCode, http.StatusCreated)
+		}
+	}
+
+	// A signed request whose JSON body is swapped afterwards must fail.
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/albums", strings.NewReader(`{"title":"a","artist":"b","price":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	clients["hmac-sha256"].SignRequest(req)
+	swapped := `{"title":"a","artist":"b","price":0}`
+	req.Body = io.NopCloser(strings.NewReader(swapped))
+	req.ContentLength = int64(len(swapped))
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST with swapped body = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
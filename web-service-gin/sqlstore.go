@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"example.com/albums/albumdb"
+)
+
+// sqlStore is an AlbumStore backed by the album table that data-access
+// uses too, in MySQL or SQLite. Its ids are integers, so the idGenerator
+// must be monotonic; an id that isn't a number matches no album.
+type sqlStore struct {
+	db *sql.DB
+	d  albumdb.Dialect
+}
+
+// newSQLStore returns a store using the album table, which has to be up to
+// date with the shared migrations, the second of which seeds it. With
+// migrate the pending ones are applied; otherwise they are an error, since
+// a migration such as 0003_unique_album can need the data fixed by hand
+// and is better run on purpose, e.g. with data-access's migrate up.
+func newSQLStore(ctx context.Context, db *sql.DB, d albumdb.Dialect, migrate bool) (*sqlStore, error) {
+	m, err := albumdb.NewMigrator(db, d)
+	if err != nil {
+		return nil, err
+	}
+	if migrate {
+		if _, err := m.Up(ctx); err != nil {
+			return nil, err
+		}
+		return &sqlStore{db: db, d: d}, nil
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			return nil, fmt.Errorf("migration %d_%s is pending; run it with data-access's migrate up, or start with -db-migrate", s.Version, s.Name)
+		}
+	}
+	return &sqlStore{db: db, d: d}, nil
+}
+
+// List returns the albums by id, which is their insertion order since the
+// idGenerator counts up.
+func (s *sqlStore) List() ([]album, error) {
+	rows, err := s.db.Query("SELECT id, title, artist, price FROM album ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var albums []album
+	for rows.Next() {
+		var a album
+		var id int64
+		if err := rows.Scan(&id, &a.Title, &a.Artist, &a.Price); err != nil {
+			return nil, err
+		}
+		a.ID = strconv.FormatInt(id, 10)
+		albums = append(albums, a)
+	}
+	return albums, rows.Err()
+}
+
+func (s *sqlStore) Get(id string) (album, error) {
+	n, err := albumID(id)
+	if err != nil {
+		return album{}, err
+	}
+	a := album{ID: id}
+	err = s.db.QueryRow(albumdb.Bind(s.d, "SELECT title, artist, price FROM album WHERE id = ?"), n).Scan(&a.Title, &a.Artist, &a.Price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return album{}, fmt.Errorf("album %s: %w", id, ErrAlbumNotFound)
+	}
+	return a, err
+}
+
+func (s *sqlStore) Add(a album) error {
+	return s.insert(s.db, a)
+}
+
+func (s *sqlStore) AddBatch(albums []album) []error {
+	errs := make([]error, len(albums))
+	fail := func(err error) []error {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+		return errs
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fail(err)
+	}
+	// A failed INSERT only undoes itself in MySQL and SQLite, so the other
+	// albums of the batch can still be committed.
+	for i, a := range albums {
+		err := s.insert(tx, a)
+		if errors.Is(err, ErrDuplicateAlbum) {
+			errs[i] = err
+		} else if err != nil {
+			tx.Rollback()
+			return fail(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+	return errs
+}
+
+// insert adds a with its id. The id or the title and artist being taken
+// already is ErrDuplicateAlbum.
+func (s *sqlStore) insert(q albumdb.Querier, a album) error {
+	n, err := strconv.ParseInt(a.ID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("album %s: the sql store needs numeric ids", a.ID)
+	}
+	_, err = q.ExecContext(context.Background(), albumdb.Bind(s.d, "INSERT INTO album (id, title, artist, price) VALUES (?, ?, ?, ?)"), n, a.Title, a.Artist, a.Price)
+	if s.d.Duplicate(err) {
+		return fmt.Errorf("album %s: %w", a.ID, ErrDuplicateAlbum)
+	}
+	return err
+}
+
+func (s *sqlStore) Update(a album) error {
+	n, err := albumID(a.ID)
+	if err != nil {
+		return err
+	}
+	// An UPDATE that changes nothing still has to find the row: SQLite counts
+	// matched rows, and MySQL does with clientFoundRows=true in the DSN.
+	result, err := s.db.Exec(albumdb.Bind(s.d, "UPDATE album SET title = ?, artist = ?, price = ? WHERE id = ?"), a.Title, a.Artist, a.Price, n)
+	if s.d.Duplicate(err) {
+		return fmt.Errorf("album %s: %w", a.ID, ErrDuplicateAlbum)
+	}
+	if err != nil {
+		return err
+	}
+	return checkFound(result, a.ID)
+}
+
+func (s *sqlStore) Delete(id string) error {
+	n, err := albumID(id)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(albumdb.Bind(s.d, "DELETE FROM album WHERE id = ?"), n)
+	if err != nil {
+		return err
+	}
+	return checkFound(result, id)
+}
+
+// albumID returns the album table's id for id. No album has an id that
+// isn't a number.
+func albumID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("album %s: %w", id, ErrAlbumNotFound)
+	}
+	return n, nil
+}
+
+// checkFound turns a statement that touched no row into ErrAlbumNotFound.
+func checkFound(result sql.Result, id string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("album %s: %w", id, ErrAlbumNotFound)
+	}
+	return nil
+}
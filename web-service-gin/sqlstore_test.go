@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/albums/albumdb"
+	"example.com/money"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSQLStore runs the AlbumStore operations against a SQLite file.
+func TestSQLStore(t *testing.T) {
+	cfg, err := loadConfig([]string{"-store=sql", "-db-dsn=" + filepath.Join(t.TempDir(), "albums.db"), "-db-max-open=4"}, func(string) string { return "" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, d, err := openDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := newSQLStore(context.Background(), db, d, false); err == nil || !strings.Contains(err.Error(), "-db-migrate") {
+		t.Fatalf("newSQLStore on an empty database without migrate = %v, want pending migrations", err)
+	}
+	s, err := newSQLStore(context.Background(), db, d, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newSQLStore(context.Background(), db, d, false); err != nil {
+		t.Errorf("newSQLStore on a migrated database without migrate: %v", err)
+	}
+
+	// The migrations seed the same albums as the other stores.
+	if albums, err := s.List(); err != nil || !slices.Equal(albums, seedAlbums()) {
+		t.Fatalf("List = %v, %v; want the seed", albums, err)
+	}
+	if err := s.Add(album{ID: "1", Title: "Giant Steps", Artist: "John Coltrane", Price: money.MustParse("63.99", money.USD)}); !errors.Is(err, ErrDuplicateAlbum) {
+		t.Errorf("Add with a taken id = %v, want ErrDuplicateAlbum", err)
+	}
+	if err := s.Add(album{ID: "9", Title: "Blue Train", Artist: "John Coltrane", Price: money.MustParse("56.99", money.USD)}); !errors.Is(err, ErrDuplicateAlbum) {
+		t.Errorf("Add with a taken title and artist = %v, want ErrDuplicateAlbum", err)
+	}
+	// AddBatch adds what it can, and List returns the albums by id.
+	giant := album{ID: "5", Title: "Giant Steps", Artist: "John Coltrane", Price: money.MustParse("63.99", money.USD)}
+	if errs := s.AddBatch([]album{giant, {ID: "4", Title: "Jeru", Artist: "Gerry Mulligan", Price: money.MustParse("17.99", money.USD)}}); errs[0] != nil || !errors.Is(errs[1], ErrDuplicateAlbum) {
+		t.Fatalf("AddBatch = %v, want the second album to be a duplicate", errs)
+	}
+	if albums, _ := s.List(); len(albums) != 4 || albums[3] != giant {
+		t.Errorf("List after AddBatch = %v, want %v last", albums, giant)
+	}
+	if err := s.Update(giant); err != nil {
+		t.Errorf("Update with unchanged values: %v", err)
+	}
+	if a, err := s.Get("5"); err != nil || a != giant {
+		t.Errorf(`Get("5") = %v, %v; want %v`, a, err, giant)
+	}
+	if err := s.Delete("5"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"5", "not-a-number"} {
+		if _, err := s.Get(id); !errors.Is(err, ErrAlbumNotFound) {
+			t.Errorf("Get(%q) error = %v, want ErrAlbumNotFound", id, err)
+		}
+	}
+
+	p := albumdb.NewProber(db, time.Minute)
+	if err := p.Probe(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.GET("/debug/db", debugDB(db, p))
+	w := serve(router, http.MethodGet, "/debug/db", "")
+	var body struct {
+		Healthy bool
+		Pool    struct {
+			MaxOpenConnections int `json:"max_open_connections"`
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK || !body.Healthy || body.Pool.MaxOpenConnections != 4 {
+		t.Errorf("GET /debug/db = %d %s", w.Code, w.Body)
+	}
+
+	// A failed ping's error stays out of the response.
+	db.Close()
+	p.Probe(context.Background())
+	w = serve(router, http.MethodGet, "/debug/db", "")
+	if w.Code != http.StatusServiceUnavailable || strings.Contains(w.Body.String(), "database is closed") {
+		t.Errorf("GET /debug/db with the database down = %d %s, want 503 without the error", w.Code, w.Body)
+	}
+}
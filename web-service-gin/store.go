@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"example.com/money"
+)
+
+var (
+	// ErrAlbumNotFound is returned by an AlbumStore when no album has the requested ID.
+	ErrAlbumNotFound = errors.New("album not found")
+	// ErrDuplicateAlbum is returned by AlbumStore.Add when the ID is already
+	// taken, and by the sql store also when the title and artist are.
+	ErrDuplicateAlbum = errors.New("album already exists")
+)
+
+// AlbumStore is the storage behind the album handlers.
+type AlbumStore interface {
+	// List returns every album in insertion order.
+	List() ([]album, error)
+	// Get returns the album with the given ID, or ErrAlbumNotFound.
+	Get(id string) (album, error)
+	// Add appends a new album to the store, or returns ErrDuplicateAlbum.
+	Add(a album) error
+	// Update replaces the album that has a.ID, or returns ErrAlbumNotFound.
+	Update(a album) error
+	// AddBatch adds albums as one unit of work: one transaction or one file
+	// write. It returns an error per album, nil for each one that was added.
+	AddBatch(albums []album) []error
+	// Delete removes the album with the given ID, or returns ErrAlbumNotFound.
+	Delete(id string) error
+}
+
+// seedAlbums is the record album data a new store starts with.
+func seedAlbums() []album {
+	return []album{
+		{ID: "1", Title: "Blue Train", Artist: "John Coltrane", Price: money.MustParse("56.99", money.USD)},
+		{ID: "2", Title: "Jeru", Artist: "Gerry Mulligan", Price: money.MustParse("17.99", money.USD)},
+		{ID: "3", Title: "Sarah Vaughan and Clifford Brown", Artist: "Sarah Vaughan", Price: money.MustParse("39.99", money.USD)},
+	}
+}
+
+// memoryStore keeps albums in a slice guarded by a sync.RWMutex,
+// so concurrent readers don't block each other but writers are exclusive.
+type memoryStore struct {
+	mu     sync.RWMutex
+	albums []album
+}
+
+// newMemoryStore returns a memoryStore holding a copy of albums.
+func newMemoryStore(albums []album) *memoryStore {
+	return &memoryStore{albums: append([]album(nil), albums...)}
+}
+
+func (s *memoryStore) List() ([]album, error) {
+	s.mu.RLock() // 读锁
+	defer s.mu.RUnlock()
+	// Return a copy so callers can't modify the slice behind the lock.
+	return append([]album(nil), s.albums...), nil
+}
+
+func (s *memoryStore) Get(id string) (album, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range s.albums {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return album{}, fmt.Errorf("album %s: %w", id, ErrAlbumNotFound)
+}
+
+func (s *memoryStore) Add(a album) error {
+	s.mu.Lock() // 写锁（独占）
+	defer s.mu.Unlock()
+	return s.add(a)
+}
+
+func (s *memoryStore) AddBatch(albums []album) []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := make([]error, len(albums))
+	for i, a := range albums {
+		errs[i] = s.add(a)
+	}
+	return errs
+}
+
+func (s *memoryStore) Update(a album) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update(a)
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.remove(id)
+}
+
+// add, update and remove change s.albums; the caller must hold s.mu.
+func (s *memoryStore) add(a album) error {
+	for _, existing := range s.albums {
+		if existing.ID == a.ID {
+			return fmt.Errorf("album %s: %w", a.ID, ErrDuplicateAlbum)
+		}
+	}
+	s.albums = append(s.albums, a)
+	return nil
+}
+
+func (s *memoryStore) update(a album) error {
+	for i := range s.albums {
+		if s.albums[i].ID == a.ID {
+			s.albums[i] = a
+			return nil
+		}
+	}
+	return fmt.Errorf("album %s: %w", a.ID, ErrAlbumNotFound)
+}
+
+func (s *memoryStore) remove(id string) error {
+	for i := range s.albums {
+		if s.albums[i].ID == id {
+			s.albums = append(s.albums[:i], s.albums[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("album %s: %w", id, ErrAlbumNotFound)
+}
+
+// fileStore is a memoryStore that writes the whole album list to a JSON file
+// after every change, so albums survive a restart.
+type fileStore struct {
+	memoryStore
+	path string
+}
+
+// newFileStore loads albums from the JSON file at path. If the file does not
+// exist yet it is created with the seed albums.
+func newFileStore(path string) (*fileStore, error) {
+	s := &fileStore{path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.albums = seedAlbums()
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("newFileStore %q: %v", path, err)
+	default:
+		if err := json.Unmarshal(data, &s.albums); err != nil {
+			return nil, fmt.Errorf("newFileStore %q: %v", path, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *fileStore) Add(a album) error {
+	return s.commit(func() error { return s.add(a) })
+}
+
+func (s *fileStore) AddBatch(albums []album) []error {
+	errs := make([]error, len(albums))
+	err := s.commit(func() error {
+		for i, a := range albums {
+			errs[i] = s.add(a)
+		}
+		return nil
+	})
+	if err != nil { // not saved, so none of them was added
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+	}
+	return errs
+}
+
+func (s *fileStore) Update(a album) error {
+	return s.commit(func() error { return s.update(a) })
+}
+
+func (s *fileStore) Delete(id string) error {
+	return s.commit(func() error { return s.remove(id) })
+}
+
+// commit runs change under the write lock and saves the result. If change or
+// the save fails, the albums are rolled back so memory matches the file.
+func (s *fileStore) commit(change func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := append([]album(nil), s.albums...)
+	if err := change(); err != nil {
+		return err
+	}
+	if err := s.save(); err != nil {
+		s.albums = before
+		return err
+	}
+	return nil
+}
+
+// save writes the albums to a temp file and renames it over path, so a crash
+// mid-write never leaves a truncated file behind. The caller must hold s.mu.
+func (s *fileStore) save() error {
+	data, err := json.MarshalIndent(s.albums, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fileStore save: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("fileStore save: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once the rename succeeded
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("fileStore save: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("fileStore save: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("fileStore save: %v", err)
+	}
+	return nil
+}
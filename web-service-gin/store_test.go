@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"example.com/money"
+)
+
+// go test -race
+
+// TestMemoryStoreConcurrentAdd adds albums from many goroutines and checks
+// that none of them are lost.
+func TestMemoryStoreConcurrentAdd(t *testing.T) {
+	s := newMemoryStore(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.Add(album{ID: strconv.Itoa(i)})
+		}(i)
+	}
+	wg.Wait()
+
+	albums, _ := s.List()
+	if len(albums) != 50 {
+		t.Errorf("len(List()) = %d, want 50", len(albums))
+	}
+}
+
+// TestFileStorePersists checks that an album added to a fileStore is still
+// there after the file is opened again.
+func TestFileStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "albums.json")
+	s, err := newFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(album{ID: "4", Title: "Giant Steps", Artist: "John Coltrane", Price: money.MustParse("63.99", money.USD)}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := newFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	albums, _ := reopened.List()
+	if len(albums) != len(seedAlbums())+1 {
+		t.Errorf("len(List()) = %d, want %d", len(albums), len(seedAlbums())+1)
+	}
+	if a, err := reopened.Get("4"); err != nil || a.Title != "Giant Steps" {
+		t.Errorf(`Get("4") = %v, %v, want Giant Steps, nil`, a, err)
+	}
+	if _, err := reopened.Get("missing"); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf(`Get("missing") error = %v, want ErrAlbumNotFound`, err)
+	}
+}
+
+// TestStoreErrors checks that store errors can be told apart with errors.Is
+// while still naming the album.
+func TestStoreErrors(t *testing.T) {
+	s := newMemoryStore(seedAlbums())
+
+	err := s.Add(album{ID: "1", Title: "Blue Train", Artist: "John Coltrane", Price: money.MustParse("56.99", money.USD)})
+	if !errors.Is(err, ErrDuplicateAlbum) {
+		t.Errorf("Add duplicate error = %v, want ErrDuplicateAlbum", err)
+	}
+	for name, err := range map[string]error{
+		"Update": s.Update(album{ID: "42"}),
+		"Delete": s.Delete("42"),
+	} {
+		if !errors.Is(err, ErrAlbumNotFound) || !strings.Contains(err.Error(), "42") {
+			t.Errorf("%s error = %v, want ErrAlbumNotFound for album 42", name, err)
+		}
+	}
+
+	var invalid *ValidationError
+	if err := (album{ID: "5", Price: money.MustParse("-1", money.USD)}).validate(); !errors.As(err, &invalid) || len(invalid.Fields) != 3 {
+		t.Errorf("validate error = %#v, want *ValidationError with 3 fields", err)
+	}
+	if err := (album{ID: "5", Title: "t", Artist: "a", Price: money.MustParse("1", money.USD)}).validate(); err != nil {
+		t.Errorf("validate of a valid album = %v, want nil", err)
+	}
+}
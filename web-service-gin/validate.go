@@ -0,0 +1,53 @@
+package main
+
+import "strings"
+
+// ValidationError is returned when a request breaks one or more rules.
+// Message says what was rejected ("invalid album") and Fields says why.
+type ValidationError struct {
+	Message string
+	Fields  []fieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	for i, f := range e.Fields {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(f.Field + " " + f.Message)
+	}
+	return b.String()
+}
+
+// invalidAlbum returns a *ValidationError for an album body.
+func invalidAlbum(fields ...fieldError) *ValidationError {
+	return &ValidationError{Message: "invalid album", Fields: fields}
+}
+
+// validate checks a against the album rules and returns a *ValidationError
+// with one fieldError per broken rule, or nil if a is valid. Uniqueness of
+// the ID is checked by the store, since only it can do so atomically.
+func (a album) validate() error {
+	var errs []fieldError
+	if strings.TrimSpace(a.ID) == "" {
+		errs = append(errs, fieldError{Field: "id", Message: "is required"})
+	}
+	if strings.TrimSpace(a.Title) == "" {
+		errs = append(errs, fieldError{Field: "title", Message: "is required"})
+	}
+	if strings.TrimSpace(a.Artist) == "" {
+		errs = append(errs, fieldError{Field: "artist", Message: "is required"})
+	}
+	// Extra decimals are rejected when the price is parsed, see priceError.
+	if a.Price.IsNegative() {
+		errs = append(errs, fieldError{Field: "price", Message: "must not be negative"})
+	}
+	if errs != nil {
+		return invalidAlbum(errs...)
+	}
+	return nil // not a nil *ValidationError, which would be a non-nil error
+}